@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eskelinenantti/tmuxide/internal/picker"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell"
+	"github.com/eskelinenantti/tmuxide/internal/shell/git"
+	"github.com/eskelinenantti/tmuxide/internal/xdg"
+)
+
+var ErrRemoteBranch = errors.New("--branch only works for local projects")
+
+// checkout lets the user pick a branch of the repository of the project, and
+// returns the target in the worktree of that branch. The branch checked out
+// in the repository itself opens the repository, other branches are checked
+// out in worktrees of their own, so that each branch gets a session of its
+// own. It returns false if the user cancelled picking.
+func checkout(target string, proj project.Project, shell shell.Shell) (string, bool, error) {
+	if proj.WorkingDir == "" {
+		// Remote projects have no local working directory, unlike projects
+		// in containers, whose repository is on the local machine
+		return "", false, ErrRemoteBranch
+	}
+
+	root, err := shell.Git.RevParse(proj.WorkingDir)
+	if err != nil {
+		return "", false, err
+	}
+
+	branches, err := shell.Git.Branches(root)
+	if err != nil {
+		return "", false, err
+	}
+
+	branch, ok, err := picker.PickBranch(shell.Fzf, branches)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	dir, err := worktree(root, branch, shell)
+	if err != nil {
+		return "", false, fmt.Errorf("could not check out %s: %w", branch.Name, err)
+	}
+
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return dir, true, nil
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return dir, true, nil
+	}
+	return filepath.Join(dir, rel), true, nil
+}
+
+// worktree returns the worktree of the branch, adding it if the branch is not
+// checked out yet. Remote branches, such as origin/feature, are checked out
+// as a local branch tracking them.
+func worktree(root string, branch git.Branch, shell shell.Shell) (string, error) {
+	worktrees, err := shell.Git.Worktrees(root)
+	if err != nil {
+		return "", err
+	}
+	if dir, ok := worktrees[branch.Local()]; ok {
+		return dir, nil
+	}
+
+	dir := worktreeDir(root, branch.Local())
+	if !shell.DryRun {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", err
+		}
+	}
+
+	var startPoint string
+	if branch.Remote != "" {
+		startPoint = branch.Name
+	}
+	return dir, shell.Git.AddWorktree(root, dir, branch.Local(), startPoint)
+}
+
+// worktreeDir returns the directory of the worktree of the branch. Worktrees
+// are kept apart from the repositories, and are named after the repository
+// and the branch, e.g. tmuxide@feature-login, which also names their
+// sessions.
+func worktreeDir(root string, branch string) string {
+	name := filepath.Base(root) + "@" + strings.ReplaceAll(branch, "/", "-")
+	return filepath.Join(xdg.DataHome(), "worktrees", project.Name(root), name)
+}
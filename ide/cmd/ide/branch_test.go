@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/git"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/eskelinenantti/tmuxide/internal/xdg"
+	"github.com/google/go-cmp/cmp"
+)
+
+const refs = "refs/heads/main\n" +
+	"refs/heads/fix\n" +
+	"refs/remotes/origin/HEAD\n" +
+	"refs/remotes/origin/main\n" +
+	"refs/remotes/origin/feature/login\n"
+
+func TestBranches(t *testing.T) {
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{OnRun: mock.WriteToStdout(refs)}}}
+	branches, err := git.Cmd{Runner: spyRunner}.Branches("/src/repo")
+	requireNoError(t, err)
+
+	want := []git.Branch{
+		{Name: "main"},
+		{Name: "fix"},
+		{Name: "origin/feature/login", Remote: "origin"},
+	}
+	if diff := cmp.Diff(want, branches); diff != "" {
+		t.Fatal(diff)
+	}
+	if local := branches[2].Local(); local != "feature/login" {
+		t.Fatalf("got=%s, want=feature/login", local)
+	}
+}
+
+func TestBranchWorktree(t *testing.T) {
+	unsetenv(t, "TMUX")
+	t.Setenv("EDITOR", editor)
+
+	repository := t.TempDir()
+	file := createFile(t, repository, "file.txt")
+	worktree := filepath.Join(xdg.DataHome(), "worktrees", project.Name(repository), filepath.Base(repository)+"@feature-login")
+	worktreeFile := filepath.Join(worktree, "file.txt")
+	session := project.Name(worktree)
+
+	addWorktree := func(cmd *exec.Cmd) error {
+		if err := os.MkdirAll(worktree, 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(worktreeFile, nil, 0644)
+	}
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(repository)},
+		{OnRun: mock.WriteToStdout(repository)},
+		{OnRun: mock.WriteToStdout(refs)},
+		respondFzfVersion,
+		{OnRun: mock.WriteToStdout("origin/feature/login\n")},
+		{OnRun: mock.WriteToStdout("worktree " + repository + "\nHEAD 1a2b3c\nbranch refs/heads/main\n")},
+		{OnRun: addWorktree},
+		{OnRun: mock.WriteToStdout(worktree)},
+		{OnRun: mock.SimulateError},
+		{OnRun: mock.SimulateError},
+	}}
+
+	err := Ide([]string{file}, Options{Branch: true}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"git", "-C", repository, "rev-parse", "--show-toplevel"},
+		{"git", "-C", repository, "rev-parse", "--show-toplevel"},
+		{"git", "-C", repository, "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes"},
+		fzfVersion,
+		{"fzf", "--reverse", "--height", "70%", "--tmux", "70%"},
+		{"git", "-C", repository, "worktree", "list", "--porcelain"},
+		{"git", "-C", repository, "worktree", "add", "--track", "-b", "feature/login", worktree, "origin/feature/login"},
+		{"git", "-C", worktree, "rev-parse", "--show-toplevel"},
+		{"tmux", "has-session", "-t", session + ":" + editor},
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", worktree, "-d", "-s", session, editor, worktreeFile},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestBranchInExistingWorktree(t *testing.T) {
+	unsetenv(t, "TMUX")
+	t.Setenv("EDITOR", editor)
+
+	repository := t.TempDir()
+	worktree := t.TempDir()
+	session := project.Name(worktree)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(repository)},
+		{OnRun: mock.WriteToStdout(refs)},
+		respondFzfVersion,
+		{OnRun: mock.WriteToStdout("fix\n")},
+		{OnRun: mock.WriteToStdout("worktree " + repository + "\nbranch refs/heads/main\n\nworktree " + worktree + "\nbranch refs/heads/fix\n")},
+		{OnRun: mock.SimulateError},
+	}}
+
+	err := Ide([]string{repository}, Options{Branch: true}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"git", "-C", repository, "rev-parse", "--show-toplevel"},
+		{"git", "-C", repository, "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes"},
+		fzfVersion,
+		{"fzf", "--reverse", "--height", "70%", "--tmux", "70%"},
+		{"git", "-C", repository, "worktree", "list", "--porcelain"},
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", worktree, "-d", "-s", session},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestBranchWithRelativeTarget(t *testing.T) {
+	unsetenv(t, "TMUX")
+	t.Setenv("EDITOR", editor)
+
+	repository := t.TempDir()
+	createFile(t, createDir(t, repository, "cmd"), "main.go")
+	t.Chdir(repository)
+	worktree := t.TempDir()
+	worktreeFile := createFile(t, createDir(t, worktree, "cmd"), "main.go")
+	session := project.Name(worktree)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(repository)},
+		{OnRun: mock.WriteToStdout(repository)},
+		{OnRun: mock.WriteToStdout(refs)},
+		respondFzfVersion,
+		{OnRun: mock.WriteToStdout("fix\n")},
+		{OnRun: mock.WriteToStdout("worktree " + repository + "\nbranch refs/heads/main\n\nworktree " + worktree + "\nbranch refs/heads/fix\n")},
+		{OnRun: mock.WriteToStdout(worktree)},
+		{OnRun: mock.SimulateError},
+		{OnRun: mock.SimulateError},
+	}}
+
+	err := Ide([]string{"cmd/main.go"}, Options{Branch: true}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"git", "-C", "cmd", "rev-parse", "--show-toplevel"},
+		{"git", "-C", repository, "rev-parse", "--show-toplevel"},
+		{"git", "-C", repository, "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes"},
+		fzfVersion,
+		{"fzf", "--reverse", "--height", "70%", "--tmux", "70%"},
+		{"git", "-C", repository, "worktree", "list", "--porcelain"},
+		{"git", "-C", filepath.Dir(worktreeFile), "rev-parse", "--show-toplevel"},
+		{"tmux", "has-session", "-t", session + ":" + editor},
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", worktree, "-d", "-s", session, editor, worktreeFile},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestBranchInDevcontainer(t *testing.T) {
+	unsetenv(t, "TMUX")
+	t.Setenv("EDITOR", editor)
+
+	repository := t.TempDir()
+	writeFile(t, repository, ".devcontainer.json", `{}`)
+	session := project.Name(repository)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout("0123456789ab\n")},
+		{OnRun: mock.WriteToStdout("true\n")},
+		{OnRun: mock.WriteToStdout(repository)},
+		{OnRun: mock.WriteToStdout(refs)},
+		respondFzfVersion,
+		{OnRun: mock.WriteToStdout("main\n")},
+		{OnRun: mock.WriteToStdout("worktree " + repository + "\nbranch refs/heads/main\n")},
+		{OnRun: mock.WriteToStdout("0123456789ab\n")},
+		{OnRun: mock.WriteToStdout("true\n")},
+		{OnRun: mock.SimulateError},
+	}}
+
+	err := Ide([]string{repository}, Options{Branch: true}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	workspace := "/workspaces/" + filepath.Base(repository)
+	devcontainer := []string{"docker", "exec", "-it", "-w", workspace, "0123456789ab"}
+	requireCalls(t, [][]string{
+		{"docker", "ps", "--all", "--quiet", "--filter", "label=devcontainer.local_folder=" + repository},
+		{"docker", "inspect", "--format", "{{.State.Running}}", "0123456789ab"},
+		{"git", "-C", repository, "rev-parse", "--show-toplevel"},
+		{"git", "-C", repository, "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes"},
+		fzfVersion,
+		{"fzf", "--reverse", "--height", "70%", "--tmux", "70%"},
+		{"git", "-C", repository, "worktree", "list", "--porcelain"},
+		{"docker", "ps", "--all", "--quiet", "--filter", "label=devcontainer.local_folder=" + repository},
+		{"docker", "inspect", "--format", "{{.State.Running}}", "0123456789ab"},
+		{"tmux", "has-session", "-t", session + ":"},
+		append([]string{"tmux", "new-session", "-c", repository, "-d", "-s", session}, append(devcontainer, "sh", "-c", "exec ${SHELL:-sh} -l")...),
+		{"tmux", "set-option", "-t", session + ":", "default-command", strings.Join(devcontainer, " ") + " sh -c 'exec ${SHELL:-sh} -l'"},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
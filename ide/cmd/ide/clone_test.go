@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/repository"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+)
+
+func TestRepositoryDir(t *testing.T) {
+	tests := []struct {
+		url   string
+		dir   string
+		isGit bool
+	}{
+		{url: "https://github.com/org/repo", dir: "root/github.com/org/repo", isGit: true},
+		{url: "https://github.com/org/repo.git", dir: "root/github.com/org/repo", isGit: true},
+		{url: "http://example.com:8080/group/sub/repo.git", dir: "root/example.com/group/sub/repo", isGit: true},
+		{url: "git://example.com/repo", dir: "root/example.com/repo", isGit: true},
+		{url: "ssh://git@github.com:22/org/repo", dir: "root/github.com/org/repo", isGit: true},
+		{url: "ssh://example.com/srv/repo.git", dir: "root/example.com/srv/repo", isGit: true},
+		{url: "git@github.com:org/repo.git", dir: "root/github.com/org/repo", isGit: true},
+		{url: "git@github.com:org/repo", dir: "root/github.com/org/repo", isGit: true},
+		{url: "example.com:org/repo.git", dir: "root/example.com/org/repo", isGit: true},
+		{url: "https://github.com/../../etc/passwd", dir: "root/github.com/etc/passwd", isGit: true},
+		{url: "ssh://example.com/srv/repo"},
+		{url: "example.com:src/repo"},
+		{url: "path/to/repo"},
+		{url: "https://github.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			url, isGit := repository.Parse(tt.url)
+			if isGit != tt.isGit {
+				t.Fatalf("got=%v, want=%v", isGit, tt.isGit)
+			}
+			if dir := url.Dir("root"); isGit && dir != tt.dir {
+				t.Fatalf("got=%s, want=%s", dir, tt.dir)
+			}
+		})
+	}
+}
+
+func TestCloneWorkflow(t *testing.T) {
+	tests := []struct {
+		name   string
+		cloned bool
+	}{
+		{name: "clones and opens repository"},
+		{name: "opens cloned repository", cloned: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			unsetenv(t, "TMUX")
+			root := t.TempDir()
+			writeConfig(t, `{"clone_root": "`+root+`"}`)
+
+			url := "git@github.com:org/repo.git"
+			dir := filepath.Join(root, "github.com/org/repo")
+			session := project.Name(dir)
+
+			var responses []spy.Response
+			var expectedCalls [][]string
+			if tt.cloned {
+				createDir(t, root, "github.com/org/repo")
+			} else {
+				responses = append(responses, spy.Response{OnRun: func(cmd *exec.Cmd) error {
+					return os.Mkdir(dir, 0755)
+				}})
+				expectedCalls = append(expectedCalls, []string{"git", "clone", "--", url, dir})
+			}
+			responses = append(responses, spy.Response{OnRun: mock.SimulateError})
+			expectedCalls = append(expectedCalls,
+				[]string{"tmux", "has-session", "-t", session + ":"},
+				[]string{"tmux", "new-session", "-c", dir, "-d", "-s", session},
+				listPanes(session),
+				[]string{"tmux", "attach", "-t", session + ":"},
+			)
+
+			spyRunner := &spy.SpyRunner{Responses: responses}
+			err := Ide([]string{url}, Options{}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			requireCalls(t, expectedCalls, spyRunner.Calls)
+		})
+	}
+}
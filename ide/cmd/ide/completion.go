@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"cmp"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eskelinenantti/tmuxide/internal/history"
+	"github.com/eskelinenantti/tmuxide/internal/shell/path"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish",
+	Short: "Print the shell completion script.",
+	Long: `Print the completion script of the shell. Completing a file or folder suggests
+the folders of tmuxide sessions, recently opened files and folders, and the
+repositories under the clone root, before the files and folders that match.
+
+To load the completions in every shell, add one of these to the configuration
+of your shell:
+
+  bash: source <(ide completion bash)
+  zsh:  source <(ide completion zsh)
+  fish: ide completion fish | source`,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return Completion(args[0], cmd.OutOrStdout())
+	},
+}
+
+func Completion(shell string, output io.Writer) error {
+	switch shell {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(output, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(output)
+	default:
+		return rootCmd.GenFishCompletion(output, true)
+	}
+}
+
+// completeTarget completes the file or folder argument of a command.
+func completeTarget(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	// The shells complete files only when there are no suggestions, so the
+	// files are suggested after the other targets
+	targets := Targets(toComplete, global, runner.CmdRunner{}, path.Path{})
+	files := Files(toComplete)
+	directive := cobra.ShellCompDirectiveNoFileComp
+	if len(files) == 1 && strings.HasSuffix(files[0], "/") {
+		// Let the folder be completed further
+		directive |= cobra.ShellCompDirectiveNoSpace
+	}
+	return append(targets, files...), directive
+}
+
+// Files returns the files and folders starting with toComplete, the way the
+// shell would complete them. Folders end with a slash, and hidden files are
+// only included when toComplete names them.
+func Files(toComplete string) []cobra.Completion {
+	dir, prefix := filepath.Split(toComplete)
+	readDir := cmp.Or(dir, ".")
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		readDir = filepath.Join(os.Getenv("HOME"), rest)
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var files []cobra.Completion
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if isDir, _ := isDir(filepath.Join(readDir, name)); isDir {
+			name += "/"
+		}
+		files = append(files, dir+name)
+	}
+	return files
+}
+
+// Targets returns the targets starting with toComplete: the folders of the
+// running tmuxide sessions, the history by how often and recently the targets
+// have been opened, and the repositories under the clone root. Completing has
+// to be fast, so the files under $HOME are never listed.
+func Targets(toComplete string, global Global, runner runner.Runner, path path.ShellPath) []cobra.Completion {
+	var targets []cobra.Completion
+	seen := map[string]bool{}
+	add := func(target string, description string) {
+		target = abbreviate(target, toComplete)
+		if seen[target] || !strings.HasPrefix(target, toComplete) {
+			return
+		}
+		seen[target] = true
+		targets = append(targets, cobra.CompletionWithDesc(target, description))
+	}
+
+	shell, config, err := setup(global, runner, path)
+	if err == nil {
+		// The server is not running if listing the sessions fails
+		sessions, _ := shell.Tmux.ListSessions()
+		for _, session := range sessions {
+			if target, ok := sessionTarget(session); ok {
+				add(target, "session "+session.Name)
+			}
+		}
+	}
+
+	// A broken history only leaves out the recent targets
+	entries, _ := history.Load()
+	for _, entry := range entries {
+		add(entry.Target, "recent")
+	}
+
+	for _, repository := range repositories(config.Root()) {
+		add(repository, "repository")
+	}
+	return targets
+}
+
+// repositories returns the repositories cloned under the root, which are in
+// subdirectories by host and path, e.g. github.com/owner/repository.
+func repositories(root string) []string {
+	var repositories []string
+	matches, _ := filepath.Glob(filepath.Join(root, "*", "*", "*", ".git"))
+	for _, match := range matches {
+		repositories = append(repositories, filepath.Dir(match))
+	}
+	return repositories
+}
+
+// abbreviate writes the path relative to the home directory with ~ when the
+// path being completed starts with ~, as the shell leaves it unexpanded.
+func abbreviate(path string, toComplete string) string {
+	home := os.Getenv("HOME")
+	if !strings.HasPrefix(toComplete, "~") || home == "" {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~/" + rest
+	}
+	return path
+}
+
+func init() {
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.ValidArgsFunction = completeTarget
+	killCmd.ValidArgsFunction = completeTarget
+	runCmd.ValidArgsFunction = completeTarget
+	rootCmd.AddCommand(completionCmd)
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/history"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+)
+
+func TestTargets(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	session := createDir(t, home, "api")
+	recent := createFile(t, home, "notes.md")
+	repository := filepath.Join(home, "src", "github.com", "owner", "repository")
+	if err := os.MkdirAll(filepath.Join(repository, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{recent, session} {
+		requireNoError(t, history.Add(target))
+	}
+
+	sessions := project.Name(session) + "\t" + session + "\n" +
+		"main\t" + home + "\n"
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{OnRun: mock.WriteToStdout(sessions)}}}
+	targets := Targets("", Global{}, spyRunner, mock.Path{})
+
+	want := []cobra.Completion{
+		session + "\tsession " + project.Name(session),
+		recent + "\trecent",
+		repository + "\trepository",
+	}
+	if diff := cmp.Diff(want, targets); diff != "" {
+		t.Fatal(diff)
+	}
+	requireCalls(t, [][]string{{"tmux", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"}}, spyRunner.Calls)
+}
+
+func TestTargetsFromHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	requireNoError(t, history.Add(filepath.Join(home, "notes.md")))
+	requireNoError(t, history.Add(filepath.Join(home, "api")))
+
+	targets := Targets("~/n", Global{}, &spy.SpyRunner{}, mock.Path{})
+
+	if diff := cmp.Diff([]cobra.Completion{"~/notes.md\trecent"}, targets); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := t.TempDir()
+	createFile(t, dir, "main.go")
+	createDir(t, dir, "internal")
+	createFile(t, dir, ".env")
+	createFile(t, home, "notes.md")
+
+	tests := []struct {
+		toComplete string
+		want       []cobra.Completion
+	}{
+		{toComplete: dir + "/", want: []cobra.Completion{dir + "/internal/", dir + "/main.go"}},
+		{toComplete: dir + "/m", want: []cobra.Completion{dir + "/main.go"}},
+		{toComplete: dir + "/.", want: []cobra.Completion{dir + "/.env"}},
+		{toComplete: "~/n", want: []cobra.Completion{"~/notes.md"}},
+		{toComplete: dir + "/missing/", want: nil},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, Files(tt.toComplete)); diff != "" {
+			t.Errorf("Files(%q): %s", tt.toComplete, diff)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	unsetenv(t, "TMUX")
+
+	once := t.TempDir()
+	twice := t.TempDir()
+	for _, dir := range []string{twice, once, twice} {
+		err := Ide([]string{dir}, Options{Detach: true, Output: &bytes.Buffer{}}, &spy.SpyRunner{}, mock.Path{})
+		requireNoError(t, err)
+	}
+
+	entries, err := history.Load()
+	requireNoError(t, err)
+	var targets []string
+	for _, entry := range entries {
+		targets = append(targets, entry.Target)
+	}
+	if diff := cmp.Diff([]string{twice, once}, targets); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestBrokenHistory(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	unsetenv(t, "TMUX")
+	writeFile(t, filepath.Dir(history.Path()), filepath.Base(history.Path()), "{")
+
+	dir := t.TempDir()
+	err := Ide([]string{dir}, Options{Detach: true, Output: &bytes.Buffer{}}, &spy.SpyRunner{}, mock.Path{})
+	requireNoError(t, err)
+
+	// The broken history is started over
+	entries, err := history.Load()
+	requireNoError(t, err)
+	if len(entries) != 1 || entries[0].Target != dir {
+		t.Fatalf("got=%v, want only %s", entries, dir)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			var output bytes.Buffer
+			err := Completion(shell, &output)
+			requireNoError(t, err)
+
+			if !strings.Contains(output.String(), "# "+shell+" completion") || !strings.Contains(output.String(), "__ide_") {
+				t.Errorf("got=%q, want %s completion", output.String()[:min(output.Len(), 100)], shell)
+			}
+		})
+	}
+}
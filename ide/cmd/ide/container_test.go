@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	createDir(t, dir, filepath.Dir(name))
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContainerWorkflow(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		config    string
+		running   bool
+		engine    string
+		container string
+		workspace string
+	}{
+		{
+			name:      "starts stopped container",
+			file:      config.ProjectFile,
+			config:    `{"container": {"engine": "podman", "name": "api", "workspace": "/src"}}`,
+			engine:    "podman",
+			container: "api",
+			workspace: "/src",
+		},
+		{
+			name: "uses running devcontainer",
+			file: ".devcontainer/devcontainer.json",
+			config: `{
+				// Comments are allowed in devcontainer.json
+				"name": "api",
+				"workspaceFolder": "/workspace", /* and so are trailing commas */
+			}`,
+			running:   true,
+			engine:    "docker",
+			container: "0123456789ab",
+			workspace: "/workspace",
+		},
+		{
+			name:      "uses default workspace of devcontainer",
+			file:      ".devcontainer.json",
+			config:    `{}`,
+			running:   true,
+			engine:    "docker",
+			container: "0123456789ab",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			unsetenv(t, "TMUX")
+
+			dir := t.TempDir()
+			writeFile(t, dir, tt.file, tt.config)
+			if tt.file == config.ProjectFile {
+				trustProject(t, dir)
+			}
+			file := createFile(t, createDir(t, dir, "cmd"), "main.go")
+			session := project.Name(dir)
+			workspace := tt.workspace
+			if workspace == "" {
+				workspace = "/workspaces/" + filepath.Base(dir)
+			}
+
+			responses := []spy.Response{{OnRun: mock.WriteToStdout(dir)}}
+			var expectedCalls [][]string
+			expectedCalls = append(expectedCalls, []string{"git", "-C", filepath.Dir(file), "rev-parse", "--show-toplevel"})
+			if tt.container != "api" {
+				responses = append(responses, spy.Response{OnRun: mock.WriteToStdout(tt.container + "\n")})
+				expectedCalls = append(expectedCalls, []string{tt.engine, "ps", "--all", "--quiet", "--filter", "label=devcontainer.local_folder=" + dir})
+			}
+			running := "false"
+			if tt.running {
+				running = "true"
+			}
+			responses = append(responses, spy.Response{OnRun: mock.WriteToStdout(running + "\n")})
+			expectedCalls = append(expectedCalls, []string{tt.engine, "inspect", "--format", "{{.State.Running}}", tt.container})
+			if !tt.running {
+				responses = append(responses, spy.Response{})
+				expectedCalls = append(expectedCalls, []string{tt.engine, "start", tt.container})
+			}
+
+			responses = append(responses, spy.Response{OnRun: mock.SimulateError}, spy.Response{OnRun: mock.SimulateError})
+			expectedCalls = append(expectedCalls,
+				[]string{"tmux", "has-session", "-t", session + ":" + editor},
+				[]string{"tmux", "has-session", "-t", session + ":"},
+				[]string{"tmux", "new-session", "-c", dir, "-d", "-s", session, tt.engine, "exec", "-it", "-w", workspace, tt.container, editor, workspace + "/cmd/main.go"},
+				[]string{"tmux", "set-option", "-t", session + ":", "default-command", tt.engine + " exec -it -w " + workspace + " " + tt.container + " sh -c 'exec ${SHELL:-sh} -l'"},
+				listPanes(session),
+				[]string{"tmux", "attach", "-t", session + ":"},
+			)
+
+			spyRunner := &spy.SpyRunner{Responses: responses}
+			err := Ide([]string{file}, Options{}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			requireCalls(t, expectedCalls, spyRunner.Calls)
+		})
+	}
+}
+
+func TestMissingDevcontainer(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+
+	dir := t.TempDir()
+	writeFile(t, dir, ".devcontainer/devcontainer.json", `{}`)
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout("")},
+		{OnRun: mock.SimulateError},
+	}}
+	var stderr bytes.Buffer
+	err := Ide([]string{dir}, Options{Global: Global{Stderr: &stderr}}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	if got, want := stderr.String(), "Opening "+dir+" without its devcontainer"; !strings.HasPrefix(got, want) {
+		t.Errorf("got warning=%q, want %q", got, want)
+	}
+
+	requireCalls(t, [][]string{
+		{"docker", "ps", "--all", "--quiet", "--filter", "label=devcontainer.local_folder=" + dir},
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", dir, "-d", "-s", session},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestKillKeepsContainerStopped(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, config.ProjectFile, `{"container": {"name": "api"}}`)
+	trustProject(t, dir)
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{}
+	err := Kill(dir, Global{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "kill-session", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
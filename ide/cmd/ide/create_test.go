@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+)
+
+func requireDir(t *testing.T, dir string) {
+	t.Helper()
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("%s is not a directory: %v", dir, err)
+	}
+}
+
+func requireEmptyDir(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	requireNoError(t, err)
+	if len(entries) > 0 {
+		t.Fatalf("%s was created in %s", entries[0].Name(), dir)
+	}
+}
+
+func TestCreateFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository bool
+	}{
+		{name: "creates file in repository", repository: true},
+		{name: "creates file outside repository"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			unsetenv(t, "TMUX")
+
+			repository := t.TempDir()
+			file := filepath.Join(repository, "path/to/new/file.go")
+			dir := filepath.Dir(file)
+			if tt.repository {
+				dir = repository
+			}
+			session := project.Name(dir)
+
+			gitResponse := spy.Response{OnRun: mock.SimulateError}
+			if tt.repository {
+				gitResponse = spy.Response{OnRun: mock.WriteToStdout(repository)}
+			}
+			spyRunner := &spy.SpyRunner{
+				Responses: []spy.Response{
+					gitResponse,
+					{OnRun: mock.SimulateError},
+					{OnRun: mock.SimulateError},
+				},
+			}
+
+			err := Ide([]string{file}, Options{Create: true}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			expectedCalls := [][]string{
+				{"git", "-C", repository, "rev-parse", "--show-toplevel"},
+				{"tmux", "has-session", "-t", session + ":" + editor},
+				{"tmux", "has-session", "-t", session + ":"},
+				{"tmux", "new-session", "-c", dir, "-d", "-s", session, editor, file},
+				listPanes(session),
+				{"tmux", "attach", "-t", session + ":"},
+			}
+			requireCalls(t, expectedCalls, spyRunner.Calls)
+			requireDir(t, filepath.Dir(file))
+		})
+	}
+}
+
+func TestCreateFolder(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+
+	dir := filepath.Join(t.TempDir(), "new/folder")
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{OnRun: mock.SimulateError}}}
+
+	err := Ide([]string{dir + "/"}, Options{Create: true}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	expectedCalls := [][]string{
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", dir, "-d", "-s", session},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}
+	requireCalls(t, expectedCalls, spyRunner.Calls)
+	requireDir(t, dir)
+}
+
+func TestCreateFromPrompt(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	file := filepath.Join(home, "notes/today.md")
+	dir := filepath.Dir(file)
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{
+		Responses: []spy.Response{
+			respondFzfVersion,
+			{OnRun: mock.WriteToStdout("notes/today.md\n")},
+			{},
+			{OnRun: mock.SimulateError},
+			{OnRun: mock.SimulateError},
+			{OnRun: mock.SimulateError},
+		},
+	}
+
+	err := Ide([]string{}, Options{Create: true}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	expectedCalls := [][]string{
+		fzfVersion,
+		{"fzf", "--reverse", "--height", "70%", "--tmux", "70%", "--print-query"},
+		{"fd", "--follow", "--hidden", "--exclude", "{.git,node_modules,Library}", ".", "--base-directory", home},
+		{"git", "-C", home, "rev-parse", "--show-toplevel"},
+		{"tmux", "has-session", "-t", session + ":" + editor},
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", dir, "-d", "-s", session, editor, file},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}
+	requireCalls(t, expectedCalls, spyRunner.Calls)
+	requireDir(t, dir)
+}
+
+func TestCreateRemoteTarget(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+	cwd := t.TempDir()
+	t.Chdir(cwd)
+
+	spyRunner := &spy.SpyRunner{}
+	err := Ide([]string{"devbox:src/new/"}, Options{Create: true}, spyRunner, mock.Path{})
+	if !errors.Is(err, ErrRemoteCreate) {
+		t.Fatalf("got=%v, want=%v", err, ErrRemoteCreate)
+	}
+	requireCalls(t, nil, spyRunner.Calls)
+	requireEmptyDir(t, cwd)
+}
+
+func TestCreateClonesRepository(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+	cwd := t.TempDir()
+	t.Chdir(cwd)
+	root := filepath.Join(t.TempDir(), "src")
+	writeConfig(t, `{"clone_root": "`+root+`"}`)
+
+	url := "https://github.com/org/repo"
+	dir := filepath.Join(root, "github.com/org/repo")
+	session := project.Name(dir)
+
+	cloneRepository := func(cmd *exec.Cmd) error {
+		return os.MkdirAll(dir, 0755)
+	}
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: cloneRepository},
+		{OnRun: mock.SimulateError},
+	}}
+	err := Ide([]string{url}, Options{Create: true}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"git", "clone", "--", url, dir},
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", dir, "-d", "-s", session},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+	requireEmptyDir(t, cwd)
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/shell"
+	"github.com/eskelinenantti/tmuxide/internal/shell/path"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+	"github.com/eskelinenantti/tmuxide/internal/shell/version"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that tmuxide and its dependencies are set up correctly.",
+	Long: `Check that tmuxide and its dependencies are set up correctly.
+
+Checks the installed dependencies and their versions, the editor, the
+configuration, the tmux server and whether tmuxide runs inside a client of
+the server it uses. Each problem is printed with a suggested fix, and the
+command fails if anything is broken.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return Doctor(global, cmd.OutOrStdout(), commandRunner(global, cmd), path.Path{})
+	},
+}
+
+var ErrDoctorFailed = errors.New("some checks failed")
+
+type status string
+
+const (
+	pass status = "ok"
+	warn status = "warn"
+	fail status = "fail"
+)
+
+// result is the outcome of a check, with a fix if it did not pass.
+type result struct {
+	status status
+	name   string
+	detail string
+	fix    string
+}
+
+func Doctor(global Global, output io.Writer, runner runner.Runner, path path.ShellPath) error {
+	versions := version.Cmd{Runner: runner, Cache: &version.Cache{}}
+	var results []result
+	for _, dependency := range shell.Dependencies {
+		results = append(results, checkDependency(dependency, versions, path))
+	}
+
+	results = append(results, checkEditor(path))
+
+	cfg, configResult := checkConfig()
+	results = append(results, configResult)
+
+	tmux := tmux.Cmd{Runner: runner, Socket: tmux.Socket(cmp.Or(global.Socket, cfg.Socket))}
+	socket, serverResult := checkServer(tmux)
+	results = append(results, serverResult, checkClient(socket))
+
+	failed := false
+	for _, result := range results {
+		fmt.Fprintf(output, "%-4s  %s: %s\n", result.status, result.name, result.detail)
+		if result.fix != "" {
+			fmt.Fprintf(output, "      %s\n", result.fix)
+		}
+		failed = failed || result.status == fail
+	}
+
+	if failed {
+		return ErrDoctorFailed
+	}
+	return nil
+}
+
+func checkDependency(dependency string, versions version.Cmd, path path.ShellPath) result {
+	if !path.Contains(dependency) {
+		return result{fail, dependency, "not installed", fmt.Sprintf("Install %s, e.g. with: brew install %[1]s", dependency)}
+	}
+
+	installed, err := versions.Of(dependency)
+	if err != nil {
+		return result{warn, dependency, fmt.Sprintf("could not determine the version: %v", err), ""}
+	}
+
+	var required version.Version
+	var missing []string
+	for _, feature := range version.Features {
+		if feature.Program == dependency && !installed.AtLeast(feature.Since) {
+			missing = append(missing, feature.Name)
+			if feature.Since.AtLeast(required) {
+				required = feature.Since
+			}
+		}
+	}
+
+	// Older versions still work, with the missing features degraded
+	if len(missing) > 0 {
+		detail := fmt.Sprintf("%s is older than %s, which is needed for %s", installed, required, strings.Join(missing, " and "))
+		return result{warn, dependency, detail, fmt.Sprintf("Upgrade %s, e.g. with: brew upgrade %[1]s", dependency)}
+	}
+	return result{pass, dependency, installed.String(), ""}
+}
+
+func checkEditor(path path.ShellPath) result {
+	editor, err := editorCmd(path)
+	switch {
+	case errors.Is(err, ErrEditorEnvNotSet):
+		return result{fail, "editor", "$EDITOR is not set", "Set the editor in your ~/.zshrc or ~/.bashrc, e.g.: export EDITOR=vim"}
+	case errors.Is(err, ErrEditorNotInstalled):
+		detail := fmt.Sprintf("%s from $EDITOR was not found", os.Getenv("EDITOR"))
+		return result{fail, "editor", detail, "Install the editor, or set $EDITOR to an editor in your $PATH"}
+	}
+	return result{pass, "editor", strings.Join(editor, " "), ""}
+}
+
+func checkConfig() (config.Config, result) {
+	cfg, err := config.Load()
+	if err == nil {
+		_, err = cfg.Timeout()
+	}
+	if err == nil {
+		_, err = cfg.Level()
+	}
+	if err != nil {
+		return cfg, result{fail, "config", err.Error(), fmt.Sprintf("Fix the configuration in %s", config.Path())}
+	}
+
+	if _, err := os.Stat(config.Path()); err != nil {
+		return cfg, result{pass, "config", "using the defaults, " + config.Path() + " does not exist", ""}
+	}
+	return cfg, result{pass, "config", config.Path(), ""}
+}
+
+func checkServer(tmux tmux.Cmd) (string, result) {
+	socket, err := tmux.SocketPath()
+	if err != nil || socket == "" {
+		return "", result{warn, "tmux server", "not running", "The server is started when you open a session"}
+	}
+	return socket, result{pass, "tmux server", socket, ""}
+}
+
+// checkClient checks whether $TMUX belongs to the server tmuxide uses. If it
+// does not, tmuxide attaches a nested client instead of switching sessions.
+func checkClient(socket string) result {
+	env, ok := os.LookupEnv("TMUX")
+	if !ok {
+		return result{pass, "$TMUX", "not inside tmux", ""}
+	}
+
+	clientSocket, _, _ := strings.Cut(env, ",")
+	if socket != "" && clientSocket != socket {
+		detail := fmt.Sprintf("inside a client of %s, but tmuxide uses %s and opens sessions in nested clients", clientSocket, socket)
+		return result{warn, "$TMUX", detail, fmt.Sprintf("Pass --socket %s to use the server of the current client", clientSocket)}
+	}
+	return result{pass, "$TMUX", "inside a client of " + clientSocket, ""}
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
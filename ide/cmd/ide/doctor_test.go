@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+)
+
+func respondVersions(versions ...string) []spy.Response {
+	var responses []spy.Response
+	for _, version := range versions {
+		responses = append(responses, spy.Response{OnRun: mock.WriteToStdout(version)})
+	}
+	return responses
+}
+
+func TestDoctor(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	setAttached(t)
+
+	spyRunner := &spy.SpyRunner{Responses: append(
+		respondVersions("tmux 3.4\n", "fd 10.2.0\n", "0.56.3 (brew)\n", "git version 2.47.1\n"),
+		spy.Response{OnRun: mock.WriteToStdout(socketPath)},
+	)}
+	var output bytes.Buffer
+	err := Doctor(Global{}, &output, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	want := "ok    tmux: 3.4.0\n" +
+		"ok    fd: 10.2.0\n" +
+		"ok    fzf: 0.56.3\n" +
+		"ok    git: 2.47.1\n" +
+		"ok    editor: " + editor + "\n" +
+		"ok    config: using the defaults, " + config.Path() + " does not exist\n" +
+		"ok    tmux server: " + socketPath + "\n" +
+		"ok    $TMUX: inside a client of " + socketPath + "\n"
+	if diff := cmp.Diff(want, output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	requireCalls(t, [][]string{
+		{"tmux", "-V"},
+		{"fd", "--version"},
+		{"fzf", "--version"},
+		{"git", "--version"},
+		displaySocketPath,
+	}, spyRunner.Calls)
+}
+
+func TestDoctorFindsProblems(t *testing.T) {
+	unsetenv(t, "EDITOR")
+	t.Setenv("TMUX", "/tmp/tmux-1000/other,1234,0")
+	writeConfig(t, `{"hook_timeout": "soon"}`)
+
+	spyRunner := &spy.SpyRunner{Responses: append(
+		respondVersions("tmux 3.1c\n", "0.44.1 (debian)\n", "git version 2.47.1\n"),
+		spy.Response{OnRun: mock.WriteToStdout(socketPath)},
+	)}
+	var output bytes.Buffer
+	err := Doctor(Global{}, &output, spyRunner, mock.Path{Missing: []string{"fd"}})
+	if !errors.Is(err, ErrDoctorFailed) {
+		t.Fatalf("got=%v, want=%v", err, ErrDoctorFailed)
+	}
+
+	for _, want := range []string{
+		"warn  tmux: 3.1.0 is older than 3.2.0, which is needed for popups and new-session -e\n      Upgrade tmux",
+		"fail  fd: not installed\n      Install fd",
+		"warn  fzf: 0.44.1 is older than 0.53.0, which is needed for --tmux\n      Upgrade fzf",
+		"ok    git: 2.47.1\n",
+		"fail  editor: $EDITOR is not set\n",
+		"fail  config: ",
+		"warn  $TMUX: inside a client of /tmp/tmux-1000/other, but tmuxide uses " + socketPath,
+	} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("%q not in output:\n%s", want, output.String())
+		}
+	}
+}
+
+func TestDoctorWithoutServer(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+
+	spyRunner := &spy.SpyRunner{Responses: append(
+		respondVersions("tmux 3.4\n", "fd 10.2.0\n", "0.56.3 (brew)\n", "git version 2.47.1\n"),
+		spy.Response{OnRun: mock.SimulateError},
+	)}
+	var output bytes.Buffer
+	err := Doctor(Global{Socket: "work"}, &output, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	for _, want := range []string{
+		"warn  tmux server: not running\n      The server is started when you open a session\n",
+		"ok    $TMUX: not inside tmux\n",
+	} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("%q not in output:\n%s", want, output.String())
+		}
+	}
+	requireCalls(t, [][]string{{"tmux", "-L", "work", "display-message", "-p", "#{socket_path}"}}, spyRunner.Calls[4:])
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/state"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/eskelinenantti/tmuxide/internal/tmuxconf"
+	"github.com/eskelinenantti/tmuxide/internal/trust"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	unsetenv(t, "TMUX")
+
+	dir := t.TempDir()
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{OnRun: mock.SimulateError}}}
+	var output bytes.Buffer
+	dryRunner := runner.DryRunner{Runner: spyRunner, Output: &output}
+	err := Ide([]string{dir}, Options{Global: Global{DryRun: true}}, dryRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{{"tmux", "has-session", "-t", session + ":"}}, spyRunner.Calls)
+
+	want := "# tmux has-session -t " + session + ":\n" +
+		"tmux new-session -c " + dir + " -d -s " + session + "\n" +
+		"tmux attach -t " + session + ":\n"
+	if diff := cmp.Diff(want, output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	sessions, err := state.Load()
+	requireNoError(t, err)
+	if len(sessions) != 0 {
+		t.Fatalf("got=%v, want no recorded sessions", sessions)
+	}
+}
+
+func TestDryRunCreate(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+
+	dir := filepath.Join(t.TempDir(), "new") + "/"
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{OnRun: mock.SimulateError}}}
+	dryRunner := runner.DryRunner{Runner: spyRunner, Output: &bytes.Buffer{}}
+	err := Ide([]string{dir}, Options{Global: Global{DryRun: true}, Create: true}, dryRunner, mock.Path{})
+	requireNoError(t, err)
+
+	if isDir, _ := isDir(dir); isDir {
+		t.Fatalf("%s was created", dir)
+	}
+}
+
+func TestDryRunClone(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+	root := filepath.Join(t.TempDir(), "src")
+	writeConfig(t, `{"clone_root": "`+root+`"}`)
+
+	url := "git@github.com:org/repo.git"
+	dir := filepath.Join(root, "github.com/org/repo")
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{OnRun: mock.SimulateError}}}
+	var output bytes.Buffer
+	dryRunner := runner.DryRunner{Runner: spyRunner, Output: &output}
+	err := Ide([]string{url}, Options{Global: Global{DryRun: true}}, dryRunner, mock.Path{})
+	requireNoError(t, err)
+
+	want := "git clone -- " + url + " " + dir + "\n" +
+		"# tmux has-session -t " + session + ":\n" +
+		"tmux new-session -c " + dir + " -d -s " + session + "\n" +
+		"tmux attach -t " + session + ":\n"
+	if diff := cmp.Diff(want, output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+	if _, err := os.Stat(root); err == nil {
+		t.Fatalf("%s was created", root)
+	}
+}
+
+func TestDryRunConfigFiles(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dir := t.TempDir()
+	tmuxConfig := filepath.Join(dir, "tmux.conf")
+	writeFile(t, dir, config.ProjectFile, `{}`)
+	dryRun := Global{DryRun: true}
+
+	var output bytes.Buffer
+	requireNoError(t, Install(InstallOptions{Global: dryRun, File: tmuxConfig}, &output))
+	requireNoError(t, Trust(dir, dryRun, &output))
+	if _, err := os.Stat(tmuxConfig); err == nil {
+		t.Fatalf("%s was written", tmuxConfig)
+	}
+	if _, err := os.Stat(trust.Path()); err == nil {
+		t.Fatalf("%s was written", trust.Path())
+	}
+
+	requireNoError(t, Install(InstallOptions{File: tmuxConfig}, &output))
+	requireNoError(t, Uninstall(InstallOptions{Global: dryRun, File: tmuxConfig}, &output))
+	if content, _ := os.ReadFile(tmuxConfig); string(content) != tmuxconf.Block {
+		t.Fatalf("got=%q, want the key bindings kept", content)
+	}
+}
+
+func TestIsQuery(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{args: []string{"tmux", "-L", "work", "has-session", "-t", "api:"}, want: true},
+		{args: []string{"tmux", "display-message", "-p", "#{socket_path}"}, want: true},
+		{args: []string{"tmux", "display-message", "could not open"}, want: false},
+		{args: []string{"tmux", "-S", "/tmp/tmux", "new-session", "-d"}, want: false},
+		{args: []string{"tmux", "-V"}, want: true},
+		{args: []string{"git", "-C", "/src", "rev-parse", "--show-toplevel"}, want: true},
+		{args: []string{"git", "clone", "--", "https://github.com/a/b", "/src/b"}, want: false},
+		{args: []string{"git", "-C", "/src", "worktree", "list", "--porcelain"}, want: true},
+		{args: []string{"git", "-C", "/src", "worktree", "add", "/src@main", "main"}, want: false},
+		{args: []string{"docker", "inspect", "api"}, want: true},
+		{args: []string{"docker", "start", "api"}, want: false},
+		{args: []string{"fd", "--follow", "."}, want: true},
+		{args: []string{"sh", "-c", "direnv allow"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := runner.IsQuery(tt.args); got != tt.want {
+			t.Errorf("IsQuery(%q)=%v, want=%v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{}, {OnRun: mock.SimulateError}}}
+	var output bytes.Buffer
+	logRunner := runner.LogRunner{Runner: spyRunner, Output: &output}
+
+	_ = logRunner.Run(exec.Command("tmux", "has-session", "-t", "api:"))
+	_ = logRunner.Run(exec.Command("tmux", "new-session", "-e", "API_TOKEN=secret"))
+
+	want := regexp.MustCompile(`^tmux has-session -t api: \(ok, .+\)\n` +
+		`tmux new-session -e 'API_TOKEN=\*\*\*' \(failed, .+\)\n$`)
+	if !want.MatchString(output.String()) {
+		t.Fatalf("got=%q, want=%v", output.String(), want)
+	}
+}
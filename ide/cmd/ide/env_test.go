@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/quote"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+)
+
+func TestProjectEnvironment(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+
+	dir := t.TempDir()
+	writeFile(t, dir, ".tmuxide.json", `{
+		"env": {"PORT": "8080", "STAGE": "dev"},
+		"env_files": [".env", ".envrc", "missing.env"]
+	}`)
+	writeFile(t, dir, ".env", "# Local settings\nDATABASE_URL=\"postgres://localhost/app\"\nPORT=3000\n")
+	writeFile(t, dir, ".envrc", "use nix\nexport API_TOKEN='secret'\n")
+	trustProject(t, dir)
+	file := createFile(t, dir, "main.go")
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(dir)},
+		{OnRun: mock.SimulateError},
+		{OnRun: mock.SimulateError},
+		respondTmuxVersion,
+	}}
+	err := Ide([]string{file}, Options{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"git", "-C", dir, "rev-parse", "--show-toplevel"},
+		{"tmux", "has-session", "-t", session + ":" + editor},
+		{"tmux", "has-session", "-t", session + ":"},
+		tmuxVersion,
+		{"tmux", "new-session", "-c", dir, "-d",
+			"-e", "API_TOKEN=secret",
+			"-e", "DATABASE_URL=postgres://localhost/app",
+			"-e", "PORT=8080",
+			"-e", "STAGE=dev",
+			"-s", session, editor, file},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestContainerEnvironment(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+
+	dir := t.TempDir()
+	writeFile(t, dir, ".tmuxide.json", `{
+		"container": {"name": "api", "workspace": "/src"},
+		"env": {"STAGE": "dev"}
+	}`)
+	trustProject(t, dir)
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout("true\n")},
+		{OnRun: mock.SimulateError},
+		respondTmuxVersion,
+	}}
+	err := Ide([]string{dir}, Options{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	shell := []string{"docker", "exec", "-it", "-w", "/src", "-e", "STAGE", "api", "sh", "-c", "exec ${SHELL:-sh} -l"}
+	requireCalls(t, [][]string{
+		{"docker", "inspect", "--format", "{{.State.Running}}", "api"},
+		{"tmux", "has-session", "-t", session + ":"},
+		tmuxVersion,
+		append([]string{"tmux", "new-session", "-c", dir, "-d", "-e", "STAGE=dev", "-s", session}, shell...),
+		{"tmux", "set-option", "-t", session + ":", "default-command", quote.Join(shell)},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestSecretsAreNotInErrors(t *testing.T) {
+	err := runner.CmdRunner{}.Run(exec.Command("sh", "-c", "exit 1", "-e", "API_TOKEN=secret"))
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Fatalf("got=%v, want error without the secret", err)
+	}
+	if !strings.Contains(err.Error(), "API_TOKEN=***") {
+		t.Errorf("got=%v, want redacted variable", err)
+	}
+}
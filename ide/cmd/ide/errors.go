@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/ide"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell"
+	"github.com/eskelinenantti/tmuxide/internal/shell/fd"
+	"github.com/eskelinenantti/tmuxide/internal/shell/fzf"
+	"github.com/eskelinenantti/tmuxide/internal/shell/git"
+	"github.com/eskelinenantti/tmuxide/internal/shell/quote"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+)
+
+var helpNoEditorConfigured = `
+No editor was configured. Specify the editor you would like to use by setting the $EDITOR variable.
+For example, to use Vim as your editor, add the following line to your ~/.zshrc or ~/.bashrc:
+
+export EDITOR=vim`
+
+var helpEditorNotInstalled = `
+Did not find the editor in $EDITOR. Check that $EDITOR names an installed editor.`
+
+var helpCommandNotInstalledTemplate = `
+Did not find %s, which is a required dependency for ide command.
+
+You can install %[1]s e.g. via homebrew by running:
+brew install %[1]s`
+
+var helpServerNotRunning = `
+The tmux server is not running. Open a session with ide to start it, or check
+that --socket names the server you meant.`
+
+var helpSessionNotFound = `
+The tmux session does not exist. Run ide ls to list the sessions.`
+
+var helpNoClient = `
+There is no tmux client to switch. Run ide inside tmux, or pass the tty of a
+client with --client. tmux list-clients lists the clients.`
+
+var helpNotAttached = `
+--window, --split and --popup open the target in the current session, so they
+only work inside a client of the tmux server.`
+
+var helpNotRepository = `
+The folder is not inside a git repository.`
+
+var helpCloneFailed = `
+git could not clone the repository. Check the URL, and that you have access to
+the repository.`
+
+var helpSearchFailed = `
+fd could not list the files for the picker. Check that $HOME is an existing
+folder.`
+
+var helpFzfFailed = `
+fzf exited with an error. Run ide doctor to check that fzf is recent enough.`
+
+var helpInvalidPath = `
+The file or folder does not exist. Use --create to create it.`
+
+var helpInvalidConfigTemplate = `
+Fix the configuration in %s, or run ide doctor to check it.`
+
+var helpVerbose = `
+Run with --verbose to see the output of the command.`
+
+// explain returns a short explanation of the error with a hint on how to fix
+// it, or false for errors without a known cause.
+func explain(err error) (string, bool) {
+	var notInstalled shell.NotInstalledError
+	var fileErr config.FileError
+	switch {
+	case errors.Is(err, ErrEditorEnvNotSet):
+		return helpNoEditorConfigured, true
+	case errors.Is(err, ErrEditorNotInstalled):
+		return helpEditorNotInstalled, true
+	case errors.As(err, &notInstalled):
+		return fmt.Sprintf(helpCommandNotInstalledTemplate, notInstalled.Cmd), true
+	case errors.Is(err, tmux.ErrServerNotRunning):
+		return helpServerNotRunning, true
+	case errors.Is(err, tmux.ErrSessionNotFound):
+		return helpSessionNotFound, true
+	case errors.Is(err, tmux.ErrNoClient):
+		return helpNoClient, true
+	case errors.Is(err, ide.ErrNotAttached):
+		return helpNotAttached, true
+	case errors.Is(err, git.ErrNotRepository):
+		return helpNotRepository, true
+	case errors.Is(err, git.ErrCloneFailed):
+		return helpCloneFailed, true
+	case errors.Is(err, fd.ErrSearchFailed):
+		return helpSearchFailed, true
+	case errors.Is(err, fzf.ErrFailed):
+		return helpFzfFailed, true
+	case errors.Is(err, project.ErrInvalidPath):
+		return helpInvalidPath, true
+	case errors.As(err, &fileErr):
+		return fmt.Sprintf(helpInvalidConfigTemplate, fileErr.Path), true
+	case errors.Is(err, config.ErrInvalidConfig):
+		return fmt.Sprintf(helpInvalidConfigTemplate, config.Path()), true
+	}
+	return "", false
+}
+
+// render prints the explanation of the error. With verbose, it also prints
+// the command that failed and what the command printed to stderr.
+func render(output io.Writer, err error, verbose bool) {
+	if help, ok := explain(err); ok {
+		fmt.Fprintln(output, help)
+	}
+
+	var cmdErr runner.Error
+	if !errors.As(err, &cmdErr) {
+		return
+	}
+
+	if !verbose {
+		if cmdErr.Stderr != "" {
+			fmt.Fprintln(output, helpVerbose)
+		}
+		return
+	}
+
+	fmt.Fprintf(output, "\nCommand: %s\n", quote.Join(runner.Redact(cmdErr.Args)))
+	if cmdErr.Stderr != "" {
+		fmt.Fprintf(output, "Output:\n%s\n", cmdErr.Stderr)
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+)
+
+func failWithStderr(stderr string) spy.Response {
+	return spy.Response{OnRun: func(cmd *exec.Cmd) error {
+		return runner.Error{Args: cmd.Args, Stderr: stderr, Err: errors.New("exit status 1")}
+	}}
+}
+
+func TestCommandStderr(t *testing.T) {
+	err := runner.CmdRunner{}.Run(exec.Command("sh", "-c", "echo oops >&2; exit 1"))
+	if !errors.Is(err, runner.ErrCommandFailed) {
+		t.Fatalf("got=%v, want=%v", err, runner.ErrCommandFailed)
+	}
+	if diff := cmp.Diff("oops", runner.Stderr(err)); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestTmuxErrors(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   error
+	}{
+		{stderr: "no server running on /tmp/tmux-1000/default", want: tmux.ErrServerNotRunning},
+		{stderr: "error connecting to /tmp/tmux-1000/work (No such file or directory)", want: tmux.ErrServerNotRunning},
+		{stderr: "no current client", want: tmux.ErrNoClient},
+		{stderr: "can't find session: api", want: tmux.ErrSessionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stderr, func(t *testing.T) {
+			spyRunner := &spy.SpyRunner{Responses: []spy.Response{failWithStderr(tt.stderr)}}
+			err := Kill("", Global{}, spyRunner, mock.Path{})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got=%v, want=%v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidPathError(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+
+	err := Ide([]string{"missing/file.txt"}, Options{}, &spy.SpyRunner{}, mock.Path{})
+
+	var pathErr project.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "missing/file.txt" {
+		t.Fatalf("got=%v, want path error of missing/file.txt", err)
+	}
+}
+
+func TestInvalidProjectConfigError(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	dir := t.TempDir()
+	writeFile(t, dir, ".devcontainer/devcontainer.json", "{")
+
+	err := Ide([]string{dir}, Options{}, &spy.SpyRunner{}, mock.Path{})
+	if !errors.Is(err, config.ErrInvalidConfig) {
+		t.Fatalf("got=%v, want=%v", err, config.ErrInvalidConfig)
+	}
+
+	var output bytes.Buffer
+	render(&output, err, false)
+	want := "Fix the configuration in " + filepath.Join(dir, ".devcontainer/devcontainer.json")
+	if !strings.Contains(output.String(), want) {
+		t.Fatalf("%q not in output:\n%s", want, output.String())
+	}
+}
+
+func TestRender(t *testing.T) {
+	cmdErr := runner.Error{
+		Args:   []string{"tmux", "new-session", "-e", "API_TOKEN=secret", "-s", "api"},
+		Stderr: "no server running on /tmp/tmux-1000/default",
+		Err:    errors.New("exit status 1"),
+	}
+	tests := []struct {
+		name    string
+		err     error
+		verbose bool
+		want    []string
+	}{
+		{
+			name: "explains error",
+			err:  ErrEditorEnvNotSet,
+			want: []string{"export EDITOR=vim"},
+		},
+		{
+			name: "suggests verbose",
+			err:  errors.Join(tmux.ErrServerNotRunning, cmdErr),
+			want: []string{"The tmux server is not running.", "Run with --verbose"},
+		},
+		{
+			name:    "prints command and output",
+			err:     errors.Join(tmux.ErrServerNotRunning, cmdErr),
+			verbose: true,
+			want: []string{
+				"Command: tmux new-session -e 'API_TOKEN=***' -s api\n",
+				"Output:\nno server running on /tmp/tmux-1000/default\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			render(&output, tt.err, tt.verbose)
+
+			for _, want := range tt.want {
+				if !strings.Contains(output.String(), want) {
+					t.Errorf("%q not in output:\n%s", want, output.String())
+				}
+			}
+			if strings.Contains(output.String(), "secret") {
+				t.Errorf("secret in output:\n%s", output.String())
+			}
+		})
+	}
+}
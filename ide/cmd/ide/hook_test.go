@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"errors"
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/ide"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/hook"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+)
+
+const hooks = `{"hooks": {
+	"create": ["direnv allow"],
+	"switch": ["nvm use"],
+	"kill": ["docker compose down"]
+}}`
+
+func TestHooks(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+	writeConfig(t, hooks)
+
+	dir := t.TempDir()
+	writeFile(t, dir, config.ProjectFile, `{"hooks": {"create": ["docker compose up -d"]}}`)
+	trustProject(t, dir)
+	session := project.Name(dir)
+
+	var hookCmd *exec.Cmd
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.SimulateError},
+		{},
+		{OnRun: func(cmd *exec.Cmd) error {
+			hookCmd = cmd
+			return nil
+		}},
+	}}
+	err := Ide([]string{dir}, Options{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", dir, "-d", "-s", session},
+		{"sh", "-c", "direnv allow"},
+		{"sh", "-c", "docker compose up -d"},
+		listPanes(session),
+		{"sh", "-c", "nvm use"},
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+
+	if hookCmd.Dir != dir {
+		t.Errorf("dir=%s, want=%s", hookCmd.Dir, dir)
+	}
+	for _, env := range []string{"TMUXIDE_PROJECT=" + session, "TMUXIDE_ROOT=" + dir, "TMUXIDE_TARGET=" + dir, "TMUXIDE_EVENT=create"} {
+		if !slices.Contains(hookCmd.Env, env) {
+			t.Errorf("%s not in the environment of the hook", env)
+		}
+	}
+}
+
+func TestHooksOfExistingSession(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+	writeConfig(t, hooks)
+
+	dir := t.TempDir()
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{}
+	err := Ide([]string{dir}, Options{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"tmux", "has-session", "-t", session + ":"},
+		listPanes(session),
+		{"sh", "-c", "nvm use"},
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestHookFails(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+	writeConfig(t, hooks)
+
+	dir := t.TempDir()
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.SimulateError},
+		{},
+		{OnRun: mock.SimulateError},
+	}}
+	err := Ide([]string{dir}, Options{}, spyRunner, mock.Path{})
+
+	var hookErr hook.Error
+	if !errors.As(err, &hookErr) || hookErr.Event != project.Create || hookErr.Command != "direnv allow" {
+		t.Fatalf("got=%v, want create hook error", err)
+	}
+	requireCalls(t, [][]string{
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", dir, "-d", "-s", session},
+		{"sh", "-c", "direnv allow"},
+	}, spyRunner.Calls)
+}
+
+func TestHookTimeout(t *testing.T) {
+	cmd := hook.Cmd{Runner: runner.CmdRunner{}, Timeout: 10 * time.Millisecond}
+	err := cmd.Run("sleep 5", t.TempDir(), nil)
+	if !errors.Is(err, hook.ErrTimeout) {
+		t.Fatalf("got=%v, want=%v", err, hook.ErrTimeout)
+	}
+}
+
+func TestInvalidHookTimeout(t *testing.T) {
+	writeConfig(t, `{"hook_timeout": "soon"}`)
+
+	err := Ide([]string{t.TempDir()}, Options{}, &spy.SpyRunner{}, mock.Path{})
+	if !errors.Is(err, config.ErrInvalidConfig) {
+		t.Fatalf("got=%v, want=%v", err, config.ErrInvalidConfig)
+	}
+}
+
+func TestKill(t *testing.T) {
+	writeConfig(t, hooks)
+
+	dir := t.TempDir()
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{}
+	err := Kill(dir, Global{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"tmux", "has-session", "-t", session + ":"},
+		{"sh", "-c", "docker compose down"},
+		{"tmux", "kill-session", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestKillCurrentSession(t *testing.T) {
+	dir := t.TempDir()
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(session + "\t" + dir + "\n")},
+	}}
+	err := Kill("", Global{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"tmux", "display-message", "-p", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "kill-session", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestKillCurrentRemoteSession(t *testing.T) {
+	writeConfig(t, `{"ssh": "`+fakeSsh+`"}`)
+	dir := "/home/user/src/repo"
+	session := project.RemoteName("devbox", dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(session + "\t" + t.TempDir() + "\tdevbox:" + dir + "\n")},
+		{OnRun: mock.WriteToStdout(dir + "\nd\n" + dir + "\n")},
+	}}
+	err := Kill("", Global{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	calls := spyRunner.Calls
+	if diff := cmp.Diff([]string{fakeSsh, "devbox"}, calls[1][:2]); diff != "" {
+		t.Fatal(diff)
+	}
+	requireCalls(t, [][]string{
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "kill-session", "-t", session + ":"},
+	}, calls[2:])
+}
+
+func TestKillOtherSession(t *testing.T) {
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout("main\t" + t.TempDir() + "\n")},
+	}}
+	err := Kill("", Global{}, spyRunner, mock.Path{})
+	if !errors.Is(err, ErrNotProjectSession) {
+		t.Fatalf("got=%v, want=%v", err, ErrNotProjectSession)
+	}
+}
+
+func TestKillWithoutSession(t *testing.T) {
+	dir := t.TempDir()
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{OnRun: mock.SimulateError}}}
+	err := Kill(dir, Global{}, spyRunner, mock.Path{})
+	if !errors.Is(err, ide.ErrNoSession) {
+		t.Fatalf("got=%v, want=%v", err, ide.ErrNoSession)
+	}
+	requireCalls(t, [][]string{{"tmux", "has-session", "-t", project.Name(dir) + ":"}}, spyRunner.Calls)
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/eskelinenantti/tmuxide/internal/tmuxconf"
+	"github.com/spf13/cobra"
+)
+
+var installCmd = &cobra.Command{
+	Use:   "tmux-install",
+	Short: "Add the key bindings of tmuxide to the tmux configuration.",
+	Long: `Add the key bindings of tmuxide to the tmux configuration, ~/.tmux.conf by
+default:
+
+  prefix o  pick a file or folder to open
+  prefix O  open a menu to pick a project, switch to a recent session, kill
+            the session of the current project or open the file under the
+            cursor in copy mode
+
+The bindings are added between marker comments, so running the command again
+updates them, and ide tmux-uninstall removes them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		options := installOptions
+		options.Global = global
+		return Install(options, cmd.OutOrStdout())
+	},
+}
+
+var uninstallCmd = &cobra.Command{
+	Use:   "tmux-uninstall",
+	Short: "Remove the key bindings of tmuxide from the tmux configuration.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		options := installOptions
+		options.Global = global
+		return Uninstall(options, cmd.OutOrStdout())
+	},
+}
+
+type InstallOptions struct {
+	Global
+	// File is the tmux configuration file. Defaults to the one tmux reads.
+	File string
+	// Print prints the key bindings instead of adding them to the file.
+	Print bool
+}
+
+var installOptions InstallOptions
+
+func Install(options InstallOptions, output io.Writer) error {
+	if options.Print {
+		_, err := io.WriteString(output, tmuxconf.Block)
+		return err
+	}
+
+	file := options.File
+	if file == "" {
+		file = tmuxconf.Path()
+	}
+	config, err := readConfig(file)
+	if err != nil {
+		return err
+	}
+	config, err = tmuxconf.Install(config)
+	if err != nil {
+		return fmt.Errorf("%w in %s", err, file)
+	}
+
+	if options.DryRun {
+		_, err := fmt.Fprintf(output, "Would add the key bindings to %s\n", file)
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, []byte(config), 0644); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(output, "Added the key bindings to %s. Load them with: tmux source-file %[1]s\n", file)
+	return err
+}
+
+func Uninstall(options InstallOptions, output io.Writer) error {
+	file := options.File
+	if file == "" {
+		file = tmuxconf.Path()
+	}
+	config, err := readConfig(file)
+	if err != nil {
+		return err
+	}
+
+	config, ok, err := tmuxconf.Uninstall(config)
+	if err != nil {
+		return fmt.Errorf("%w in %s", err, file)
+	}
+	if !ok {
+		_, err := fmt.Fprintf(output, "No key bindings of tmuxide in %s\n", file)
+		return err
+	}
+	if options.DryRun {
+		_, err := fmt.Fprintf(output, "Would remove the key bindings from %s\n", file)
+		return err
+	}
+	if err := os.WriteFile(file, []byte(config), 0644); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(output, "Removed the key bindings from %s. They stay bound until the tmux server is restarted.\n", file)
+	return err
+}
+
+// readConfig returns the contents of the tmux configuration file, which is
+// empty if the file does not exist yet.
+func readConfig(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return string(data), err
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{installCmd, uninstallCmd} {
+		cmd.Flags().StringVarP(&installOptions.File, "file", "f", "", "tmux configuration file to change, instead of ~/.tmux.conf")
+		rootCmd.AddCommand(cmd)
+	}
+	installCmd.Flags().BoolVar(&installOptions.Print, "print", false, "print the key bindings instead of adding them")
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/tmuxconf"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInstall(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name: "creates config",
+			want: tmuxconf.Block,
+		},
+		{
+			name:   "appends to config",
+			config: "set -g mouse on",
+			want:   "set -g mouse on\n\n" + tmuxconf.Block,
+		},
+		{
+			name:   "replaces earlier bindings",
+			config: "set -g mouse on\n\n# >>> tmuxide >>>\nbind-key o run ide\n# <<< tmuxide <<<\nset -g base-index 1\n",
+			want:   "set -g mouse on\n\n" + tmuxconf.Block + "set -g base-index 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "tmux", "tmux.conf")
+			if tt.config != "" {
+				writeFile(t, filepath.Dir(file), filepath.Base(file), tt.config)
+			}
+
+			// Installing again leaves the config as it is
+			for range 2 {
+				err := Install(InstallOptions{File: file}, &bytes.Buffer{})
+				requireNoError(t, err)
+			}
+
+			config, err := os.ReadFile(file)
+			requireNoError(t, err)
+			if diff := cmp.Diff(tt.want, string(config)); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestInstallPrint(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var output bytes.Buffer
+	err := Install(InstallOptions{Print: true}, &output)
+	requireNoError(t, err)
+
+	if diff := cmp.Diff(tmuxconf.Block, output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+	if _, err := os.Stat(tmuxconf.Path()); err == nil {
+		t.Fatalf("%s was written", tmuxconf.Path())
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	dir := t.TempDir()
+	const config = "set -g mouse on\n"
+	file := filepath.Join(dir, "tmux.conf")
+	writeFile(t, dir, "tmux.conf", config)
+
+	err := Install(InstallOptions{File: file}, &bytes.Buffer{})
+	requireNoError(t, err)
+	err = Uninstall(InstallOptions{File: file}, &bytes.Buffer{})
+	requireNoError(t, err)
+
+	content, err := os.ReadFile(file)
+	requireNoError(t, err)
+	if diff := cmp.Diff(config, string(content)); diff != "" {
+		t.Fatal(diff)
+	}
+
+	var output bytes.Buffer
+	err = Uninstall(InstallOptions{File: file}, &output)
+	requireNoError(t, err)
+	if diff := cmp.Diff("No key bindings of tmuxide in "+file+"\n", output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestUnterminatedBindings(t *testing.T) {
+	dir := t.TempDir()
+	const config = "# >>> tmuxide >>>\nbind-key o run ide\nset -g mouse on\n"
+	file := filepath.Join(dir, "tmux.conf")
+	writeFile(t, dir, "tmux.conf", config)
+
+	err := Install(InstallOptions{File: file}, &bytes.Buffer{})
+	if !errors.Is(err, tmuxconf.ErrUnterminated) {
+		t.Fatalf("got=%v, want=%v", err, tmuxconf.ErrUnterminated)
+	}
+	err = Uninstall(InstallOptions{File: file}, &bytes.Buffer{})
+	if !errors.Is(err, tmuxconf.ErrUnterminated) {
+		t.Fatalf("got=%v, want=%v", err, tmuxconf.ErrUnterminated)
+	}
+
+	content, err := os.ReadFile(file)
+	requireNoError(t, err)
+	if diff := cmp.Diff(config, string(content)); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestTmuxConfigPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	if diff := cmp.Diff(filepath.Join(home, ".tmux.conf"), tmuxconf.Path()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	writeFile(t, configHome, "tmux/tmux.conf", "")
+	xdgConfig := filepath.Join(configHome, "tmux", "tmux.conf")
+	if diff := cmp.Diff(xdgConfig, tmuxconf.Path()); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/eskelinenantti/tmuxide/internal/ide"
+	"github.com/eskelinenantti/tmuxide/internal/shell/path"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var killCmd = &cobra.Command{
+	Use:   "kill [file|folder]",
+	Short: "Kill the session of a file or folder.",
+	Long: `Kill the session of a file or folder, or the current session if none is given.
+
+The kill hooks of the project run before the session is killed. Only sessions
+created by tmuxide can be killed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var target string
+		if len(args) > 0 {
+			target = args[0]
+		}
+		return Kill(target, global, commandRunner(global, cmd), path.Path{})
+	},
+}
+
+var ErrNotProjectSession = errors.New("the current session was not created by tmuxide")
+
+func Kill(target string, global Global, runner runner.Runner, path path.ShellPath) error {
+	shell, config, err := setup(global, runner, path)
+	if err != nil {
+		return err
+	}
+	// Killing the session doesn't need the container to be running
+	shell.Container.KeepStopped = true
+
+	if target == "" {
+		session, err := shell.Tmux.CurrentSession()
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if target, ok = sessionTarget(session); !ok {
+			return ErrNotProjectSession
+		}
+	}
+
+	proj, _, _, err := resolve(target, shell, config)
+	if err != nil {
+		return err
+	}
+	if err := ide.Kill(proj, shell.Tmux); err != nil || global.DryRun {
+		return err
+	}
+	return state.Remove(proj.Name)
+}
+
+func init() {
+	rootCmd.AddCommand(killCmd)
+}
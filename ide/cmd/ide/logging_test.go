@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/logging"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLogCommands(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	logger, closer, err := logging.Open(0)
+	requireNoError(t, err)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{}, {OnRun: mock.SimulateError}}}
+	slogRunner := runner.SlogRunner{Runner: spyRunner, Logger: logger}
+	_ = slogRunner.Run(exec.Command("tmux", "has-session", "-t", "api:"))
+	_ = slogRunner.Run(exec.Command("tmux", "new-session", "-e", "API_TOKEN=secret"))
+	requireNoError(t, closer.Close())
+
+	content, err := os.ReadFile(logging.Path())
+	requireNoError(t, err)
+	log := string(content)
+	for _, want := range []string{
+		`level=INFO msg=command`,
+		`command="tmux has-session -t api:"`,
+		`level=WARN msg="command failed"`,
+		`command="tmux new-session -e 'API_TOKEN=***'"`,
+		`err="mock error"`,
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("%q not in log:\n%s", want, log)
+		}
+	}
+	if strings.Contains(log, "secret") {
+		t.Errorf("secret in log:\n%s", log)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	writeConfig(t, `{"log_level": "warn"}`)
+
+	defer slog.SetDefault(slog.Default())
+	closeLog := openLog()
+	slogRunner := runner.SlogRunner{Runner: &spy.SpyRunner{}, Logger: slog.Default()}
+	_ = slogRunner.Run(exec.Command("tmux", "has-session", "-t", "api:"))
+	closeLog()
+
+	content, err := os.ReadFile(logging.Path())
+	requireNoError(t, err)
+	if len(content) != 0 {
+		t.Fatalf("got=%q, want nothing below warn", content)
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	_, closer, err := logging.Open(0)
+	requireNoError(t, err)
+	requireNoError(t, closer.Close())
+	requireNoError(t, os.Truncate(logging.Path(), logging.MaxSize))
+
+	_, closer, err = logging.Open(0)
+	requireNoError(t, err)
+	requireNoError(t, closer.Close())
+
+	info, err := os.Stat(logging.Path())
+	requireNoError(t, err)
+	if info.Size() != 0 {
+		t.Errorf("size=%d, want a new log", info.Size())
+	}
+	info, err = os.Stat(logging.Path() + ".1")
+	requireNoError(t, err)
+	if info.Size() != logging.MaxSize {
+		t.Errorf("size=%d, want the previous log", info.Size())
+	}
+}
+
+func TestNotify(t *testing.T) {
+	setAttached(t)
+
+	spyRunner := &spy.SpyRunner{}
+	notify(errors.New("could not open #1"), tmux.Cmd{Runner: spyRunner, Client: "/dev/ttys003"})
+
+	want := [][]string{{"tmux", "display-message", "-c", "/dev/ttys003", "ide: could not open ##1"}}
+	if diff := cmp.Diff(want, spyRunner.Calls); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/path"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+	"github.com/spf13/cobra"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List the sessions created by tmuxide.",
+	Long: `List the sessions created by tmuxide, one per line with the session name and
+directory separated by a tab.
+
+With --all, the sessions of the default tmux server and the servers listed in
+the sockets configuration are listed, prefixed with the socket of the server.
+Inside tmux, the server of $TMUX is listed instead of the default one.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		options := lsOptions
+		options.Global = global
+		options.Output = cmd.OutOrStdout()
+		return Ls(options, commandRunner(global, cmd), path.Path{})
+	},
+}
+
+const defaultSocket = "default"
+
+type LsOptions struct {
+	Global
+	// All lists the sessions of every configured tmux server.
+	All    bool
+	Output io.Writer
+}
+
+var lsOptions LsOptions
+
+func Ls(options LsOptions, runner runner.Runner, path path.ShellPath) error {
+	shell, config, err := setup(options.Global, runner, path)
+	if err != nil {
+		return err
+	}
+
+	if !options.All {
+		sessions, err := shell.Tmux.ListSessions()
+		if err != nil {
+			return err
+		}
+		return printSessions(options.Output, "", sessions)
+	}
+
+	sockets := []tmux.Socket{""}
+	for _, socket := range append(config.Sockets, string(shell.Tmux.Socket)) {
+		if !slices.Contains(sockets, tmux.Socket(socket)) {
+			sockets = append(sockets, tmux.Socket(socket))
+		}
+	}
+
+	// Servers are told apart by the path of their socket, as the default
+	// server is the one of $TMUX inside tmux, which may be configured too
+	paths := map[tmux.Socket]string{}
+	for _, socket := range sockets {
+		server := shell.Tmux
+		server.Socket = socket
+		if path, err := server.SocketPath(); err == nil {
+			paths[socket] = path
+		}
+	}
+
+	listed := map[string]bool{}
+	for _, socket := range sockets {
+		path, ok := paths[socket]
+		if !ok || listed[path] {
+			// The server is not running, or is listed already
+			continue
+		}
+		if socket == "" && slices.ContainsFunc(sockets[1:], func(other tmux.Socket) bool { return paths[other] == path }) {
+			// The configured socket names the server better
+			continue
+		}
+		listed[path] = true
+
+		server := shell.Tmux
+		server.Socket = socket
+		sessions, err := server.ListSessions()
+		if err != nil {
+			continue
+		}
+		if err := printSessions(options.Output, label(socket, path), sessions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// label names the server of the socket in the listing. The server tmux uses
+// without a socket is the default one, unless $TMUX points to another.
+func label(socket tmux.Socket, path string) string {
+	switch {
+	case socket != "":
+		return string(socket)
+	case filepath.Base(path) == defaultSocket:
+		return defaultSocket
+	default:
+		return path
+	}
+}
+
+func printSessions(output io.Writer, socket string, sessions []tmux.Session) error {
+	for _, session := range sessions {
+		target, ok := sessionTarget(session)
+		if !ok {
+			continue
+		}
+
+		var err error
+		if socket == "" {
+			_, err = fmt.Fprintf(output, "%s\t%s\n", session.Name, target)
+		} else {
+			_, err = fmt.Fprintf(output, "%s\t%s\t%s\n", socket, session.Name, target)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionTarget returns the target that opens the session, and whether the
+// session was created by tmuxide. Sessions of remote projects are recorded
+// with their remote target, as their path is the directory ide was run in.
+func sessionTarget(session tmux.Session) (string, bool) {
+	if session.Remote != "" {
+		return session.Remote, true
+	}
+	return session.Path, project.IsSession(session.Name, session.Path)
+}
+
+func init() {
+	lsCmd.Flags().BoolVarP(&lsOptions.All, "all", "a", false, "list sessions from all configured tmux servers")
+	rootCmd.AddCommand(lsCmd)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLs(t *testing.T) {
+	dir := t.TempDir()
+	session := project.Name(dir)
+	sessions := session + "\t" + dir + "\nmain\t" + dir + "\n"
+	remote := project.RemoteName("devbox", "/src/repo")
+
+	tests := []struct {
+		name      string
+		options   LsOptions
+		config    string
+		responses []spy.Response
+		calls     [][]string
+		output    string
+	}{
+		{
+			name:      "lists tmuxide sessions",
+			responses: []spy.Response{{OnRun: mock.WriteToStdout(sessions)}},
+			calls: [][]string{
+				{"tmux", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+			},
+			output: session + "\t" + dir + "\n",
+		},
+		{
+			name:      "lists remote sessions with their target",
+			responses: []spy.Response{{OnRun: mock.WriteToStdout(remote + "\t" + dir + "\tdevbox:/src/repo\n")}},
+			calls: [][]string{
+				{"tmux", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+			},
+			output: remote + "\tdevbox:/src/repo\n",
+		},
+		{
+			name:      "lists sessions of socket",
+			options:   LsOptions{Global: Global{Socket: "work"}},
+			responses: []spy.Response{{OnRun: mock.WriteToStdout(sessions)}},
+			calls: [][]string{
+				{"tmux", "-L", "work", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+			},
+			output: session + "\t" + dir + "\n",
+		},
+		{
+			name:    "lists sessions of all servers",
+			options: LsOptions{All: true},
+			config:  `{"sockets": ["work", "personal"]}`,
+			responses: []spy.Response{
+				{OnRun: mock.WriteToStdout("/tmp/tmux-1000/default\n")},
+				{OnRun: mock.SimulateError},
+				{OnRun: mock.WriteToStdout("/tmp/tmux-1000/personal\n")},
+				{OnRun: mock.WriteToStdout(sessions)},
+				{OnRun: mock.WriteToStdout(sessions)},
+			},
+			calls: [][]string{
+				displaySocketPath,
+				{"tmux", "-L", "work", "display-message", "-p", "#{socket_path}"},
+				{"tmux", "-L", "personal", "display-message", "-p", "#{socket_path}"},
+				{"tmux", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+				{"tmux", "-L", "personal", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+			},
+			output: "default\t" + session + "\t" + dir + "\npersonal\t" + session + "\t" + dir + "\n",
+		},
+		{
+			name:    "lists server of $TMUX once",
+			options: LsOptions{All: true},
+			config:  `{"sockets": ["work"]}`,
+			responses: []spy.Response{
+				{OnRun: mock.WriteToStdout("/tmp/tmux-1000/work\n")},
+				{OnRun: mock.WriteToStdout("/tmp/tmux-1000/work\n")},
+				{OnRun: mock.WriteToStdout(sessions)},
+			},
+			calls: [][]string{
+				displaySocketPath,
+				{"tmux", "-L", "work", "display-message", "-p", "#{socket_path}"},
+				{"tmux", "-L", "work", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+			},
+			output: "work\t" + session + "\t" + dir + "\n",
+		},
+		{
+			name:    "labels server of $TMUX by its socket",
+			options: LsOptions{All: true},
+			responses: []spy.Response{
+				{OnRun: mock.WriteToStdout("/run/tmux/other\n")},
+				{OnRun: mock.WriteToStdout(sessions)},
+			},
+			calls: [][]string{
+				displaySocketPath,
+				{"tmux", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+			},
+			output: "/run/tmux/other\t" + session + "\t" + dir + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.config != "" {
+				writeConfig(t, tt.config)
+			}
+
+			var output bytes.Buffer
+			tt.options.Output = &output
+			spyRunner := &spy.SpyRunner{Responses: tt.responses}
+
+			err := Ls(tt.options, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			requireCalls(t, tt.calls, spyRunner.Calls)
+			if diff := cmp.Diff(tt.output, output.String()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/eskelinenantti/tmuxide/internal/picker"
+	"github.com/eskelinenantti/tmuxide/internal/shell/path"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/spf13/cobra"
+)
+
+var pickFromPaneCmd = &cobra.Command{
+	Use:   "pick-from-pane",
+	Short: "Open a file or folder printed in the current pane.",
+	Long: `Pick a file or folder printed in the current pane, such as a file in the
+output of a compiler, and open it the same way as ide [file|folder] does.
+
+Paths are resolved against the working directory of the pane, and only the
+files and folders that exist are offered. Files given with a line, like
+internal/project/project.go:42, are opened at that line.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		options := Options{Global: global, Output: cmd.OutOrStdout()}
+		return PickFromPane(options, commandRunner(global, cmd), path.Path{})
+	},
+}
+
+func PickFromPane(options Options, runner runner.Runner, path path.ShellPath) error {
+	shell, _, err := setup(options.Global, runner, path)
+	if err != nil {
+		return err
+	}
+
+	dir, err := shell.Tmux.PanePath()
+	if err != nil {
+		return err
+	}
+	output, err := shell.Tmux.CapturePane()
+	if err != nil {
+		return err
+	}
+
+	location, ok, err := picker.PickLocation(shell.Fzf, picker.Locations(output, dir))
+	if err != nil || !ok {
+		return err
+	}
+
+	options.Line = location.Line
+	return Ide([]string{location.Path}, options, runner, path)
+}
+
+func init() {
+	rootCmd.AddCommand(pickFromPaneCmd)
+}
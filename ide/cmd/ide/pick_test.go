@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/picker"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLocations(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := t.TempDir()
+	file := createFile(t, createDir(t, dir, "internal"), "main.go")
+	notes := createFile(t, home, "notes.md")
+	folder := createDir(t, dir, "docs")
+
+	output := "$ go build ./...\n" +
+		"internal/main.go:42:7: undefined: foo\n" +
+		"internal/main.go:42:7: undefined: bar\n" +
+		"missing.go:1: see " + notes + " and docs/.\n" +
+		"Wrote ~/notes.md:3\n"
+
+	want := []picker.Location{
+		{Text: "~/notes.md:3", Path: notes, Line: 3},
+		{Text: notes, Path: notes},
+		{Text: "docs/", Path: folder},
+		{Text: "internal/main.go:42:7", Path: file, Line: 42},
+	}
+	if diff := cmp.Diff(want, picker.Locations(output, dir)); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestPickFromPane(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+
+	dir := t.TempDir()
+	file := createFile(t, createDir(t, dir, "internal"), "main.go")
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(dir + "\n")},
+		{OnRun: mock.WriteToStdout("$ go vet ./...\ninternal/main.go:42:7: unreachable code\n")},
+		respondFzfVersion,
+		{OnRun: mock.WriteToStdout("internal/main.go:42:7\n")},
+		{OnRun: mock.WriteToStdout(dir)},
+		{OnRun: mock.SimulateError},
+		{OnRun: mock.SimulateError},
+	}}
+	err := PickFromPane(Options{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"tmux", "display-message", "-p", "#{pane_current_path}"},
+		{"tmux", "capture-pane", "-p", "-J"},
+		fzfVersion,
+		{"fzf", "--reverse", "--height", "70%", "--tmux", "70%"},
+		{"git", "-C", filepath.Dir(file), "rev-parse", "--show-toplevel"},
+		{"tmux", "has-session", "-t", session + ":" + editor},
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", dir, "-d", "-s", session, editor, "+42", file},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestPickFromEmptyPane(t *testing.T) {
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(t.TempDir() + "\n")},
+		{OnRun: mock.WriteToStdout("$ ls\n$\n")},
+	}}
+	err := PickFromPane(Options{}, spyRunner, mock.Path{})
+	if !errors.Is(err, picker.ErrNoLocations) {
+		t.Fatalf("got=%v, want=%v", err, picker.ErrNoLocations)
+	}
+}
+
+func TestOpenFileAtLine(t *testing.T) {
+	for _, target := range []string{"file.go:42", "file.go:42:7"} {
+		t.Run(target, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			unsetenv(t, "TMUX")
+
+			dir := t.TempDir()
+			t.Chdir(dir)
+			createFile(t, dir, "file.go")
+			session := project.Name(dir)
+
+			spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+				{OnRun: mock.WriteToStdout(dir)},
+				{OnRun: mock.SimulateError},
+				{OnRun: mock.SimulateError},
+			}}
+			err := Ide([]string{target}, Options{}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			requireCalls(t, [][]string{
+				{"git", "-C", ".", "rev-parse", "--show-toplevel"},
+				{"tmux", "has-session", "-t", session + ":" + editor},
+				{"tmux", "has-session", "-t", session + ":"},
+				{"tmux", "new-session", "-c", dir, "-d", "-s", session, editor, "+42", "file.go"},
+				listPanes(session),
+				{"tmux", "attach", "-t", session + ":"},
+			}, spyRunner.Calls)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/layout"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell"
+	"github.com/eskelinenantti/tmuxide/internal/shell/container"
+	"github.com/eskelinenantti/tmuxide/internal/shell/git"
+	"github.com/eskelinenantti/tmuxide/internal/shell/hook"
+	"github.com/eskelinenantti/tmuxide/internal/shell/quote"
+	"github.com/eskelinenantti/tmuxide/internal/trust"
+)
+
+const defaultEngine = "docker"
+
+// configure applies the project configuration, such as the environment and
+// the container, to a local project, and adds the global hooks followed by
+// the hooks of the project to the project opened for target. Remote projects
+// have no project configuration.
+func configure(proj project.Project, target string, hooks config.Hooks, shell shell.Shell) (project.Project, error) {
+	var projectConfig config.Project
+	var err error
+	if proj.Exec == nil {
+		if projectConfig, err = loadProject(proj.WorkingDir, shell.Stderr); err != nil {
+			return proj, err
+		}
+	}
+
+	if proj.Env, err = projectConfig.Environment(proj.WorkingDir); err != nil {
+		return proj, err
+	}
+
+	if projectConfig.Container != nil {
+		if proj, err = containerize(proj, *projectConfig.Container, shell); err != nil {
+			return proj, err
+		}
+	}
+
+	proj.Hooks = hook.Hooks{
+		Cmd: shell.Hook,
+		Scripts: map[project.Event][]string{
+			project.Create: slices.Concat(hooks.Create, projectConfig.Hooks.Create),
+			project.Switch: slices.Concat(hooks.Switch, projectConfig.Hooks.Switch),
+			project.Kill:   slices.Concat(hooks.Kill, projectConfig.Hooks.Kill),
+		},
+		Project: proj,
+		Target:  target,
+	}
+	slog.Info("project", "name", proj.Name, "dir", proj.WorkingDir, "target", target)
+	return proj, nil
+}
+
+// loadProject reads the configuration of a local project. The project file
+// can run commands on the machine, so it applies only after it has been
+// trusted with ide trust, and is reported and ignored otherwise. The env files
+// are trusted with it, and the configuration is parsed from the same data
+// that was checked. The ignored file is reported to stderr.
+func loadProject(dir string, stderr io.Writer) (config.Project, error) {
+	path := filepath.Join(dir, config.ProjectFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config.LoadDevcontainer(dir)
+	}
+	if err != nil {
+		return config.Project{}, err
+	}
+
+	// An invalid file is reported once it has been trusted
+	projectConfig, parseErr := config.ParseProject(path, data)
+	status, err := trust.Check(path, data, projectConfig.EnvPaths(dir))
+	if err != nil {
+		return config.Project{}, err
+	}
+
+	switch status {
+	case trust.Trusted:
+		return projectConfig, parseErr
+	case trust.Changed:
+		fmt.Fprintf(stderr, "Ignoring %s, which has changed since it was trusted. Review the changes and trust it again with: ide trust %s\n", path, quote.Quote(dir))
+	default:
+		fmt.Fprintf(stderr, "Ignoring %s, which has not been trusted. Review it and trust it with: ide trust %s\n", path, quote.Quote(dir))
+	}
+	return config.LoadDevcontainer(dir)
+}
+
+// applyTemplate adds the windows of the template to the project. Without a
+// template name, the template is detected by the marker files of the project.
+func applyTemplate(proj project.Project, name string, templates []config.Template, git git.Cmd) (project.Project, error) {
+	var template config.Template
+	if name != "" {
+		var err error
+		if template, err = layout.Find(templates, name); err != nil {
+			return proj, err
+		}
+	} else if proj.WorkingDir != "" {
+		var ok bool
+		if template, ok = layout.Detect(templates, proj.WorkingDir); !ok {
+			return proj, nil
+		}
+	}
+
+	windows, err := layout.Windows(template, proj, git)
+	if err != nil {
+		return proj, err
+	}
+	proj.Windows = windows
+	return proj, nil
+}
+
+// containerize runs the commands of the project in its container, starting
+// the container if it is stopped. Projects that only have a devcontainer
+// configuration open locally if their container can't be used, as they may
+// well be used without it.
+func containerize(proj project.Project, config config.Container, shell shell.Shell) (project.Project, error) {
+	exec, err := findContainer(proj, config, shell)
+	if err != nil && config.Devcontainer {
+		fmt.Fprintf(shell.Stderr, "Opening %s without its devcontainer: %v\n", proj.WorkingDir, err)
+		return proj, nil
+	}
+	if err != nil {
+		return proj, err
+	}
+	proj.Exec = exec
+	return proj, nil
+}
+
+func findContainer(proj project.Project, config config.Container, shell shell.Shell) (container.Container, error) {
+	dir, err := filepath.Abs(proj.WorkingDir)
+	if err != nil {
+		return container.Container{}, err
+	}
+
+	engine := cmp.Or(config.Engine, defaultEngine)
+	name := config.Name
+	if name == "" {
+		if name, err = shell.Container.FindDevcontainer(engine, dir); err != nil {
+			return container.Container{}, err
+		}
+	}
+
+	if err := shell.Container.Start(engine, name); err != nil {
+		return container.Container{}, err
+	}
+
+	return container.Container{
+		Engine:    engine,
+		Name:      name,
+		Dir:       dir,
+		Workspace: cmp.Or(config.Workspace, "/workspaces/"+filepath.Base(dir)),
+		Env:       names(proj.Env),
+	}, nil
+}
+
+// names returns the names of the KEY=value environment variables.
+func names(env []string) []string {
+	var names []string
+	for _, variable := range env {
+		name, _, _ := strings.Cut(variable, "=")
+		names = append(names, name)
+	}
+	return names
+}
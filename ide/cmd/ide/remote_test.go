@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/quote"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+)
+
+const fakeSsh = "fake-ssh"
+
+func TestRemoteWorkflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		host     string
+		path     string
+		resolved string
+		dir      string
+		remote   string
+		command  string
+	}{
+		{
+			name:     "opens remote folder",
+			target:   "example.com:src/repo",
+			host:     "example.com",
+			path:     "src/repo",
+			resolved: "/home/user/src/repo\nd\n/home/user/src/repo\n",
+			dir:      "/home/user/src/repo",
+			remote:   "example.com:/home/user/src/repo",
+		},
+		{
+			name:     "opens remote file in repository",
+			target:   "ssh://user@example.com:2222/srv/my repo/main.go",
+			host:     "ssh://user@example.com:2222",
+			path:     "/srv/my repo/main.go",
+			resolved: "/srv/my repo/main.go\nf\n/srv/my repo\n",
+			dir:      "/srv/my repo",
+			remote:   "ssh://user@example.com:2222/srv/my repo",
+			command:  "exec editor '/srv/my repo/main.go'",
+		},
+		{
+			name:     "opens remote file outside repository",
+			target:   "example.com:/etc/hosts",
+			host:     "example.com",
+			path:     "/etc/hosts",
+			resolved: "/etc/hosts\nf\n",
+			dir:      "/etc",
+			remote:   "example.com:/etc",
+			command:  "exec editor /etc/hosts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			unsetenv(t, "TMUX")
+			writeConfig(t, `{"ssh": "`+fakeSsh+`"}`)
+			t.Chdir(t.TempDir())
+
+			session := project.RemoteName(tt.host, tt.dir)
+			shell := "exec $SHELL -l"
+			cd := "cd " + tt.dir + " && "
+			if tt.dir == "/srv/my repo" {
+				cd = "cd '/srv/my repo' && "
+			}
+
+			responses := []spy.Response{
+				{OnRun: mock.WriteToStdout(tt.resolved)},
+				{OnRun: mock.SimulateError},
+			}
+			var expectedCalls [][]string
+			if tt.command == "" {
+				expectedCalls = append(expectedCalls,
+					[]string{"tmux", "has-session", "-t", session + ":"},
+					[]string{"tmux", "new-session", "-d", "-s", session, fakeSsh, "-t", tt.host, cd + shell},
+				)
+			} else {
+				responses = append(responses, spy.Response{OnRun: mock.SimulateError})
+				expectedCalls = append(expectedCalls,
+					[]string{"tmux", "has-session", "-t", session + ":" + editor},
+					[]string{"tmux", "has-session", "-t", session + ":"},
+					[]string{"tmux", "new-session", "-d", "-s", session, fakeSsh, "-t", tt.host, cd + tt.command},
+				)
+			}
+			expectedCalls = append(expectedCalls,
+				[]string{"tmux", "set-option", "-t", session + ":", "default-command", quote.Join([]string{fakeSsh, "-t", tt.host, cd + shell})},
+				[]string{"tmux", "set-option", "-t", session + ":", "@tmuxide_remote", tt.remote},
+				listPanes(session),
+				[]string{"tmux", "attach", "-t", session + ":"},
+			)
+
+			spyRunner := &spy.SpyRunner{Responses: responses}
+			err := Ide([]string{tt.target}, Options{}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			resolve := spyRunner.Calls[0]
+			if diff := cmp.Diff([]string{fakeSsh, tt.host}, resolve[:2]); diff != "" {
+				t.Fatal(diff)
+			}
+			requireCalls(t, expectedCalls, spyRunner.Calls[1:])
+		})
+	}
+}
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		target string
+		host   string
+		path   string
+		remote bool
+	}{
+		{target: "host:path/to/repo", host: "host", path: "path/to/repo", remote: true},
+		{target: "user@host:/srv/repo", host: "user@host", path: "/srv/repo", remote: true},
+		{target: "host:", host: "host", path: ".", remote: true},
+		{target: "ssh://host/path/to/repo", host: "ssh://host", path: "/path/to/repo", remote: true},
+		{target: "ssh://user@host:22/srv", host: "ssh://user@host:22", path: "/srv", remote: true},
+		{target: "path/to/repo"},
+		{target: "./dir:with/colon"},
+		{target: ":path"},
+		{target: "main.go:42"},
+		{target: "file.txt:3:7"},
+		{target: "notes.txt:draft"},
+	}
+
+	// Hosts that exist as local files are file names
+	t.Chdir(t.TempDir())
+	createFile(t, ".", "notes.txt")
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			host, path, remote := project.ParseRemote(tt.target)
+			if host != tt.host || path != tt.path || remote != tt.remote {
+				t.Fatalf("got=(%q, %q, %v), want=(%q, %q, %v)", host, path, remote, tt.host, tt.path, tt.remote)
+			}
+		})
+	}
+}
+
+func TestPickRemoteTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		cached bool
+	}{
+		{name: "lists remote host"},
+		{name: "uses cached entries", cached: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			unsetenv(t, "TMUX")
+			writeConfig(t, `{"ssh": "`+fakeSsh+`", "hosts": ["devbox"]}`)
+			cacheHome := t.TempDir()
+			t.Setenv("XDG_CACHE_HOME", cacheHome)
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+
+			if tt.cached {
+				hosts := createDir(t, cacheHome, "tmuxide/hosts")
+				createFile(t, hosts, "devbox")
+			}
+
+			dir := "/home/user/src/repo"
+			session := project.RemoteName("devbox", dir)
+
+			responses := []spy.Response{
+				respondFzfVersion,
+				{OnRun: mock.WriteToStdout("devbox:src/repo\n")},
+				{},
+			}
+			if !tt.cached {
+				responses = append(responses, spy.Response{OnRun: mock.WriteToStdout("src/repo\n")})
+			}
+			responses = append(responses,
+				spy.Response{OnRun: mock.WriteToStdout(dir + "\nd\n")},
+				spy.Response{OnRun: mock.SimulateError},
+			)
+			spyRunner := &spy.SpyRunner{Responses: responses}
+
+			err := Ide([]string{}, Options{}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			calls := spyRunner.Calls
+			if diff := cmp.Diff([]string{"fzf", "fzf", "fd"}, []string{calls[0][0], calls[1][0], calls[2][0]}); diff != "" {
+				t.Fatal(diff)
+			}
+			calls = calls[3:]
+			if !tt.cached {
+				if diff := cmp.Diff([]string{fakeSsh, "-o", "BatchMode=yes", "devbox"}, calls[0][:4]); diff != "" {
+					t.Fatal(diff)
+				}
+				calls = calls[1:]
+			}
+			if diff := cmp.Diff([]string{fakeSsh, "devbox"}, calls[0][:2]); diff != "" {
+				t.Fatal(diff)
+			}
+			if resolve := calls[0][len(calls[0])-1]; !strings.HasSuffix(resolve, " sh src/repo") {
+				t.Fatalf("got=%s, want to resolve src/repo", resolve)
+			}
+
+			expectedCalls := [][]string{
+				{"tmux", "has-session", "-t", session + ":"},
+				{"tmux", "new-session", "-d", "-s", session, fakeSsh, "-t", "devbox", "cd " + dir + " && exec $SHELL -l"},
+				{"tmux", "set-option", "-t", session + ":", "default-command", quote.Join([]string{fakeSsh, "-t", "devbox", "cd " + dir + " && exec $SHELL -l"})},
+				{"tmux", "set-option", "-t", session + ":", "@tmuxide_remote", "devbox:" + dir},
+				listPanes(session),
+				{"tmux", "attach", "-t", session + ":"},
+			}
+			requireCalls(t, expectedCalls, calls[1:])
+		})
+	}
+}
+
+func TestPickRemoteTargetListsHostsAtOnce(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+	writeConfig(t, `{"ssh": "`+fakeSsh+`", "hosts": ["devbox", "buildbox"]}`)
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	t.Setenv("HOME", t.TempDir())
+
+	// devbox answers only after buildbox, which it would never do if the
+	// hosts were listed one after another
+	buildboxListed := make(chan struct{})
+	listHost := func(cmd *exec.Cmd) error {
+		switch host := cmd.Args[3]; host {
+		case "devbox":
+			select {
+			case <-buildboxListed:
+			case <-time.After(time.Second):
+				return errors.New("devbox was listed before buildbox")
+			}
+		case "buildbox":
+			defer close(buildboxListed)
+		}
+		_, err := io.WriteString(cmd.Stdout, "src/repo\n")
+		return err
+	}
+
+	dir := "/home/user/src/repo"
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		respondFzfVersion,
+		{OnRun: mock.WriteToStdout("devbox:src/repo\n")},
+		{},
+		{OnRun: listHost},
+		{OnRun: listHost},
+		{OnRun: mock.WriteToStdout(dir + "\nd\n")},
+		{OnRun: mock.SimulateError},
+	}}
+
+	err := Ide([]string{}, Options{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	for _, host := range []string{"devbox", "buildbox"} {
+		cached, err := os.ReadFile(filepath.Join(cacheHome, "tmuxide", "hosts", host))
+		requireNoError(t, err)
+		if string(cached) != "src/repo\n" {
+			t.Fatalf("got=%q, want the entries of %s cached", cached, host)
+		}
+	}
+}
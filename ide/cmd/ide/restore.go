@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/eskelinenantti/tmuxide/internal/history"
+	"github.com/eskelinenantti/tmuxide/internal/ide"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/path"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+	"github.com/eskelinenantti/tmuxide/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Recreate the sessions of tmuxide after the tmux server has been restarted.",
+	Long: `Recreate the sessions of tmuxide after the tmux server has been restarted.
+
+tmuxide records the windows, panes, layouts and commands of its sessions
+whenever it creates or changes them. Sessions that exist already, and sessions
+whose folders have been removed, are skipped.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return Restore(global, cmd.OutOrStdout(), commandRunner(global, cmd), path.Path{})
+	},
+}
+
+var ErrRestoreFailed = errors.New("some sessions could not be restored")
+
+func Restore(global Global, output io.Writer, runner runner.Runner, path path.ShellPath) error {
+	shell, config, err := setup(global, runner, path)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := state.Load()
+	if err != nil {
+		return err
+	}
+
+	var failed bool
+	for _, session := range sessions {
+		if session.Root != "" {
+			if _, err := os.Stat(session.Root); err != nil {
+				fmt.Fprintf(output, "Skipped %s, %s no longer exists\n", session.Name, session.Root)
+				continue
+			}
+		}
+
+		if shell.Tmux.HasSession(session.Name, "") {
+			continue
+		}
+
+		proj, _, _, err := resolve(session.Target, shell, config)
+		if err == nil && proj.Name != session.Name {
+			err = fmt.Errorf("%s is now opened in session %s", session.Target, proj.Name)
+		}
+		if err == nil {
+			err = ide.Restore(session, proj, shell.Tmux)
+		}
+		if err != nil {
+			fmt.Fprintf(output, "Could not restore %s: %v\n", session.Name, err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(output, "Restored %s\n", session.Name)
+	}
+
+	if failed {
+		return ErrRestoreFailed
+	}
+	return nil
+}
+
+// record adds the target to the history, and saves the windows of the project
+// session so that ide restore can recreate them. commands are the commands of
+// the windows tmuxide has just started, by window name. The commands of the
+// other windows are kept from the earlier record of the session and the
+// template of the project.
+func record(proj project.Project, target string, commands map[string][]string, tmux tmux.Cmd) error {
+	opened := target
+	if proj.WorkingDir != "" {
+		absoluteTarget, err := filepath.Abs(target)
+		if err != nil {
+			return err
+		}
+		opened = absoluteTarget
+	}
+	if err := history.Add(opened); err != nil {
+		// A broken history only leaves out the recent targets
+		slog.Warn("history", "error", err)
+	}
+
+	windows, err := ide.Snapshot(proj.Name, tmux)
+	if err != nil || len(windows) == 0 {
+		return err
+	}
+
+	sessions, err := state.Load()
+	if err != nil {
+		return err
+	}
+
+	// Windows recorded earlier are told apart by index, as several windows
+	// can have the same name
+	earlier := map[string]state.Window{}
+	if session, ok := state.Find(sessions, proj.Name); ok {
+		for _, window := range session.Windows {
+			earlier[window.Index] = window
+		}
+	}
+	known := map[string]state.Window{}
+	for _, window := range proj.Windows {
+		known[window.Name] = state.Window{Keys: window.Command}
+	}
+	for name, command := range commands {
+		known[name] = state.Window{Command: command}
+	}
+
+	for i, window := range windows {
+		recorded, ok := known[window.Name]
+		if previous, found := earlier[window.Index]; !ok && found && previous.Name == window.Name {
+			recorded = previous
+		}
+		windows[i].Command = recorded.Command
+		windows[i].Keys = recorded.Keys
+	}
+
+	// Local projects are resolved again by their root, and remote ones by
+	// the target they were opened for
+	var root string
+	if proj.WorkingDir != "" {
+		if root, err = filepath.Abs(proj.WorkingDir); err != nil {
+			return err
+		}
+		target = root
+	}
+	return state.Put(state.Session{Name: proj.Name, Target: target, Root: root, Windows: windows})
+}
+
+// commands returns the command of the window by window name, or nothing if no
+// window was named.
+func commands(window string, command []string) map[string][]string {
+	if window == "" {
+		return nil
+	}
+	return map[string][]string{window: command}
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+}
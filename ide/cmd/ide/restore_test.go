@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/state"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRestore(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	unsetenv(t, "TMUX")
+
+	dir := t.TempDir()
+	file := createFile(t, dir, "main.go")
+	logs := createDir(t, dir, "logs")
+	session := project.Name(dir)
+
+	const layout = "b25f,80x24,0,0{40x24,0,0,1,39x24,41,0,2}"
+	panes := "0\t0\tc4de,80x24,0,0,0\t" + dir + "\t" + editor + "\n" +
+		"1\t1\t" + layout + "\t" + dir + "\tshell\n" +
+		"1\t1\t" + layout + "\t" + logs + "\tshell\n"
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(dir)},
+		{OnRun: mock.SimulateError},
+		{OnRun: mock.SimulateError},
+		{},
+		{OnRun: mock.WriteToStdout(panes)},
+	}}
+	err := Ide([]string{file}, Options{Detach: true, Output: &bytes.Buffer{}}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	spyRunner = &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.SimulateError},
+		{},
+		{OnRun: mock.WriteToStdout("1\n")},
+	}}
+	var output bytes.Buffer
+	err = Restore(Global{}, &output, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", dir, "-d", "-s", session, "-n", editor, editor, file},
+		{"tmux", "new-window", "-t", session + ":", "-c", dir, "-d", "-P", "-F", "#{window_index}", "-n", "shell"},
+		{"tmux", "split-window", "-t", session + ":1", "-c", logs, "-d"},
+		{"tmux", "select-layout", "-t", session + ":1", layout},
+		{"tmux", "select-window", "-t", session + ":1"},
+	}, spyRunner.Calls)
+
+	if diff := cmp.Diff("Restored "+session+"\n", output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestRestoreWindowsWithSameName(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	session := project.Name(dir)
+	err := state.Put(state.Session{
+		Name:   session,
+		Target: dir,
+		Root:   dir,
+		Windows: []state.Window{
+			{Index: "1", Name: "zsh", Panes: []string{dir}},
+			{Index: "2", Name: "zsh", Panes: []string{dir}, Keys: "make watch", Active: true},
+			{Index: "3", Name: "zsh", Panes: []string{dir}, Keys: "htop"},
+		},
+	})
+	requireNoError(t, err)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.SimulateError},
+		{},
+		{OnRun: mock.WriteToStdout("2\n")},
+		{},
+		{OnRun: mock.WriteToStdout("3\n")},
+	}}
+	err = Restore(Global{}, &bytes.Buffer{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"tmux", "has-session", "-t", session + ":"},
+		{"tmux", "new-session", "-c", dir, "-d", "-s", session, "-n", "zsh"},
+		{"tmux", "new-window", "-t", session + ":", "-c", dir, "-d", "-P", "-F", "#{window_index}", "-n", "zsh"},
+		{"tmux", "send-keys", "-t", session + ":2", "make watch", "Enter"},
+		{"tmux", "new-window", "-t", session + ":", "-c", dir, "-d", "-P", "-F", "#{window_index}", "-n", "zsh"},
+		{"tmux", "send-keys", "-t", session + ":3", "htop", "Enter"},
+		{"tmux", "select-window", "-t", session + ":2"},
+	}, spyRunner.Calls)
+}
+
+func TestRestoreSkipsSessions(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	existing := t.TempDir()
+	removed := filepath.Join(t.TempDir(), "removed")
+	for _, dir := range []string{existing, removed} {
+		err := state.Put(state.Session{
+			Name:    project.Name(dir),
+			Target:  dir,
+			Root:    dir,
+			Windows: []state.Window{{Name: "shell", Panes: []string{dir}}},
+		})
+		requireNoError(t, err)
+	}
+
+	spyRunner := &spy.SpyRunner{}
+	var output bytes.Buffer
+	err := Restore(Global{}, &output, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{{"tmux", "has-session", "-t", project.Name(existing) + ":"}}, spyRunner.Calls)
+	want := "Skipped " + project.Name(removed) + ", " + removed + " no longer exists\n"
+	if diff := cmp.Diff(want, output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestKillForgetsSession(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	err := state.Put(state.Session{Name: project.Name(dir), Target: dir, Root: dir})
+	requireNoError(t, err)
+
+	err = Kill(dir, Global{}, &spy.SpyRunner{}, mock.Path{})
+	requireNoError(t, err)
+
+	sessions, err := state.Load()
+	requireNoError(t, err)
+	if len(sessions) != 0 {
+		t.Fatalf("got=%v, want no sessions", sessions)
+	}
+}
+
+func TestConcurrentPutsAreKept(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Go(func() {
+			name := fmt.Sprintf("session%d", i)
+			if err := state.Put(state.Session{Name: name, Target: name}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	sessions, err := state.Load()
+	requireNoError(t, err)
+	if len(sessions) != 100 {
+		t.Fatalf("got %d sessions, want 100", len(sessions))
+	}
+}
+
+func TestRestoreFails(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	err := state.Put(state.Session{
+		Name:    "renamed",
+		Target:  dir,
+		Root:    dir,
+		Windows: []state.Window{{Name: "shell", Panes: []string{dir}}},
+	})
+	requireNoError(t, err)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{OnRun: mock.SimulateError}}}
+	var output bytes.Buffer
+	err = Restore(Global{}, &output, spyRunner, mock.Path{})
+	if !errors.Is(err, ErrRestoreFailed) {
+		t.Fatalf("got=%v, want=%v", err, ErrRestoreFailed)
+	}
+	if !strings.HasPrefix(output.String(), "Could not restore renamed: ") {
+		t.Errorf("got=%q, want failure of renamed", output.String())
+	}
+}
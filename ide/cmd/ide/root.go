@@ -1,17 +1,28 @@
 package cmd
 
 import (
+	"cmp"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/eskelinenantti/tmuxide/internal/config"
 	"github.com/eskelinenantti/tmuxide/internal/ide"
+	"github.com/eskelinenantti/tmuxide/internal/logging"
 	"github.com/eskelinenantti/tmuxide/internal/picker"
 	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/repository"
 	"github.com/eskelinenantti/tmuxide/internal/shell"
 	"github.com/eskelinenantti/tmuxide/internal/shell/path"
 	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
 	"github.com/spf13/cobra"
 )
 
@@ -25,29 +36,89 @@ session will be created (or reused) for that location automatically.
 
 When a file is selected or passed as an argument, tmuxide opens it in
 $EDITOR and creates the session for the repository root, or the file's
-directory if it is not inside a git repository.`,
+directory if it is not inside a git repository.
+
+With --branch, tmuxide then lets you pick a local or remote branch of the
+repository, and opens the location in a git worktree of that branch, so that
+each branch gets a session of its own.`,
+	Args: cobra.MaximumNArgs(1),
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		global.Stderr = cmd.ErrOrStderr()
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return Ide(args, runner.CmdRunner{}, path.Path{})
+		options := options
+		options.Global = global
+		options.Output = cmd.OutOrStdout()
+		return Ide(args, options, commandRunner(global, cmd), path.Path{})
 	},
 }
 
-var helpNoEditorConfigured = `
-No editor was configured. Specify the editor you would like to use by setting the $EDITOR variable.
-For example, to use Vim as your editor, add the following line to your ~/.zshrc or ~/.bashrc:
-				
-export EDITOR=vim`
+// Global holds the options shared by all commands.
+type Global struct {
+	// Socket is the name or path of the socket of the tmux server to use.
+	Socket string
+	// Client is the tty of the client to switch to the session.
+	Client string
+	// Verbose prints each command with how long it took, and the command that
+	// failed and its output with errors.
+	Verbose bool
+	// DryRun prints the commands that would change something instead of
+	// running them, and leaves the recorded sessions as they are.
+	DryRun bool
+	// Stderr receives the warnings of the command, such as the project
+	// configurations that are ignored. Warnings go to os.Stderr without it.
+	Stderr io.Writer
+}
 
-var helpCommandNotInstalledTemplate = `
-Did not find %s, which is a required dependency for ide command.
+type Options struct {
+	Global
+	// Detach prepares the session without switching or attaching to it, and
+	// prints the session to Output instead.
+	Detach bool
+	// JSON prints the detached session as JSON. Implies Detach.
+	JSON bool
+	// Window, Split and Popup open the target in the current session instead
+	// of switching to the session of the target.
+	Window bool
+	Split  string
+	Popup  bool
+	// Create creates the folders of targets that don't exist yet, and lets the
+	// picker return queries that match nothing as new targets.
+	Create bool
+	// Template is the name of the template to create new sessions with,
+	// instead of the one detected for the project.
+	Template string
+	// Branch lets the user pick a branch of the repository of the target, and
+	// opens the target in a worktree of that branch instead.
+	Branch bool
+	// Line is the line to open the file at. It is passed to the editor as
+	// +Line, which vi, vim, nvim, emacs, nano and most other editors accept.
+	Line   int
+	Output io.Writer
+}
 
-You can install %[1]s e.g. via homebrew by running:
-brew install %[1]s`
+// Session is the detached session printed with --json.
+type Session struct {
+	Name   string `json:"name"`
+	Dir    string `json:"dir"`
+	Window string `json:"window"`
+}
+
+var global Global
+var options Options
 
+var ErrInvalidSplit = errors.New("split must be either h or v")
 var ErrEditorNotInstalled = errors.New("editor not installed")
 var ErrEditorEnvNotSet = errors.New("editor not configured")
+var ErrRemoteCreate = errors.New("--create only works for local targets")
 
-func Ide(args []string, runner runner.Runner, path path.ShellPath) error {
-	shell, err := shell.Init(path, runner)
+func Ide(args []string, options Options, runner runner.Runner, path path.ShellPath) error {
+	pane, err := options.pane()
+	if err != nil {
+		return err
+	}
+
+	shell, config, err := setup(options.Global, runner, path)
 	if err != nil {
 		return err
 	}
@@ -59,7 +130,8 @@ func Ide(args []string, runner runner.Runner, path path.ShellPath) error {
 
 	var target string
 	if len(args) == 0 {
-		target, err = picker.Prompt(shell.Tmux, shell.Fd, shell.Fzf)
+		remotes := picker.Remotes{Ssh: shell.Ssh, Hosts: config.Hosts}
+		target, err = picker.Prompt(shell.Tmux, shell.Fd, shell.Fzf, remotes, options.Create)
 	} else {
 		target = args[0]
 	}
@@ -67,12 +139,180 @@ func Ide(args []string, runner runner.Runner, path path.ShellPath) error {
 	if target == "" || err != nil {
 		return err
 	}
+	if file, line, ok := cutLine(target); ok && options.Line == 0 {
+		target, options.Line = file, line
+	}
 
-	isDir, err := isDir(target)
+	var proj project.Project
+	var file string
+	var isDir bool
+	if options.Create {
+		proj, file, isDir, err = create(target, shell, config)
+	} else {
+		proj, file, isDir, err = resolve(target, shell, config)
+	}
+	if err != nil {
+		return err
+	}
+
+	if options.Branch {
+		var ok bool
+		target, ok, err = checkout(target, proj, shell)
+		if err != nil || !ok {
+			return err
+		}
+		if _, err := os.Stat(target); err != nil && options.DryRun {
+			// The worktree that would be added does not exist to be opened
+			return nil
+		}
+		if proj, file, isDir, err = resolve(target, shell, config); err != nil {
+			return err
+		}
+	}
+
+	proj, err = applyTemplate(proj, options.Template, config.Templates, shell.Git)
+	if err != nil {
+		return err
+	}
+
+	var command []string
+	if !isDir {
+		command = editorCmd
+		if options.Line > 0 {
+			command = append(command, fmt.Sprintf("+%d", options.Line))
+		}
+		command = append(command, file)
+	}
+
+	if pane != "" {
+		return ide.Peek(pane, command, proj, shell.Tmux)
+	}
+
+	window, err := ide.Prepare(command, proj, shell.Tmux)
 	if err != nil {
 		return err
 	}
 
+	if !options.DryRun {
+		if err := record(proj, target, commands(window, command), shell.Tmux); err != nil {
+			return err
+		}
+	}
+
+	if !options.Detach && !options.JSON {
+		return ide.Open(proj, shell.Tmux)
+	}
+	return printSession(options, proj, window)
+}
+
+func (o Options) pane() (ide.Pane, error) {
+	switch {
+	case o.Window:
+		return ide.Window, nil
+	case o.Popup:
+		return ide.Popup, nil
+	case o.Split == "h":
+		return ide.HorizontalSplit, nil
+	case o.Split == "v":
+		return ide.VerticalSplit, nil
+	case o.Split != "":
+		return "", ErrInvalidSplit
+	}
+	return "", nil
+}
+
+func printSession(options Options, proj project.Project, window string) error {
+	if !options.JSON {
+		_, err := fmt.Fprintln(options.Output, proj.Name)
+		return err
+	}
+
+	var dir string
+	if proj.WorkingDir != "" {
+		absoluteDir, err := filepath.Abs(proj.WorkingDir)
+		if err != nil {
+			return err
+		}
+		dir = absoluteDir
+	}
+
+	return json.NewEncoder(options.Output).Encode(Session{Name: proj.Name, Dir: dir, Window: window})
+}
+
+// commandRunner returns the runner for the commands of cmd. With DryRun, the
+// commands that would change something are printed to the output of cmd
+// instead, and with Verbose, the commands that run are logged to stderr.
+func commandRunner(global Global, cmd *cobra.Command) runner.Runner {
+	var commandRunner runner.Runner = runner.SlogRunner{Runner: runner.CmdRunner{}, Logger: slog.Default()}
+	if global.Verbose {
+		commandRunner = runner.LogRunner{Runner: commandRunner, Output: cmd.ErrOrStderr()}
+	}
+	if global.DryRun {
+		commandRunner = runner.DryRunner{Runner: commandRunner, Output: cmd.OutOrStdout()}
+	}
+	return commandRunner
+}
+
+// setup loads the configuration and initializes the shell commands for it.
+func setup(global Global, runner runner.Runner, path path.ShellPath) (shell.Shell, config.Config, error) {
+	shell, err := shell.Init(path, runner)
+	if err != nil {
+		return shell, config.Config{}, err
+	}
+
+	config, err := config.Load()
+	if err != nil {
+		return shell, config, err
+	}
+
+	shell.Tmux.Socket = tmux.Socket(cmp.Or(global.Socket, config.Socket))
+	shell.Tmux.Client = global.Client
+	shell.DryRun = global.DryRun
+	shell.Stderr = global.Stderr
+	if shell.Stderr == nil {
+		shell.Stderr = os.Stderr
+	}
+	shell.Ssh.Program = cmp.Or(config.Ssh, shell.Ssh.Program)
+	shell.Hook.Timeout, err = config.Timeout()
+	return shell, config, err
+}
+
+// resolve returns the project for the target file or folder, the path to open
+// the target by, and whether the target is a folder. Targets that don't exist
+// locally but are git repository URLs are cloned first, and targets that look
+// like host:path or ssh://host/path are resolved on the remote host.
+func resolve(target string, shell shell.Shell, config config.Config) (project.Project, string, bool, error) {
+	isDir, err := isDir(target)
+	if err != nil {
+		if url, ok := repository.Parse(target); ok {
+			dir, err := clone(url, shell, config.Root())
+			if err != nil {
+				return project.Project{}, "", false, fmt.Errorf("could not clone %s: %w", target, err)
+			}
+			if _, err := os.Stat(dir); err != nil && shell.DryRun {
+				// The repository that would be cloned is planned as is
+				proj, err := project.ForDir(dir)
+				if err == nil {
+					proj, err = configure(proj, target, config.Hooks, shell)
+				}
+				return proj, dir, true, err
+			}
+			return resolve(dir, shell, config)
+		}
+
+		if host, path, ok := project.ParseRemote(target); ok {
+			proj, file, isDir, err := project.ForRemote(host, path, shell.Ssh)
+			if err == nil {
+				proj, err = configure(proj, target, config.Hooks, shell)
+			}
+			if err != nil {
+				return project.Project{}, "", false, fmt.Errorf("could not open %s: %w", target, err)
+			}
+			return proj, file, isDir, nil
+		}
+		return project.Project{}, "", false, err
+	}
+
 	var proj project.Project
 	if isDir {
 		proj, err = project.ForDir(target)
@@ -81,21 +321,103 @@ func Ide(args []string, runner runner.Runner, path path.ShellPath) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("could not edit %s: %w", target, err)
+		return project.Project{}, "", false, fmt.Errorf("could not open %s: %w", target, err)
 	}
 
-	var command []string
-	if !isDir {
-		command = append(editorCmd, target)
+	proj, err = configure(proj, target, config.Hooks, shell)
+	if err != nil {
+		return project.Project{}, "", false, fmt.Errorf("could not open %s: %w", target, err)
 	}
+	return proj, proj.Path(target), isDir, nil
+}
 
-	return ide.Start(command, proj, shell.Tmux)
+// create resolves a file or folder that may not exist yet, and creates the
+// folders that are missing. Targets ending with a slash are folders, and
+// other targets are files that the editor creates when saving them. In a dry
+// run, the folders are left uncreated. Repository URLs are cloned as without
+// --create, and remote targets can't be created.
+func create(target string, shell shell.Shell, config config.Config) (project.Project, string, bool, error) {
+	if _, err := os.Stat(target); err == nil {
+		return resolve(target, shell, config)
+	}
+	if _, ok := repository.Parse(target); ok {
+		return resolve(target, shell, config)
+	}
+	if _, _, ok := project.ParseRemote(target); ok {
+		return project.Project{}, "", false, ErrRemoteCreate
+	}
+
+	isDir := strings.HasSuffix(target, "/")
+	var proj project.Project
+	var err error
+	dir := target
+	if isDir {
+		proj, err = project.ForDir(target)
+	} else {
+		proj, err = project.ForNewFile(target, shell.Git)
+		dir = filepath.Dir(target)
+	}
+	if err != nil {
+		return project.Project{}, "", false, fmt.Errorf("could not create %s: %w", target, err)
+	}
+
+	if !shell.DryRun {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return project.Project{}, "", false, fmt.Errorf("could not create %s: %w", target, err)
+		}
+	}
+
+	proj, err = configure(proj, target, config.Hooks, shell)
+	if err != nil {
+		return project.Project{}, "", false, fmt.Errorf("could not open %s: %w", target, err)
+	}
+	return proj, proj.Path(target), isDir, nil
+}
+
+// clone clones the repository under the root unless it has been cloned there
+// already, and returns the directory of the repository. In a dry run, the
+// folders of the repository are left uncreated.
+func clone(url repository.URL, shell shell.Shell, root string) (string, error) {
+	dir := url.Dir(root)
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+
+	if !shell.DryRun {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", err
+		}
+	}
+	return dir, shell.Git.Clone(url.Raw, dir)
+}
+
+// lineSuffix matches the line and the optional column that compilers and
+// linters print after a path, e.g. main.go:42:7.
+var lineSuffix = regexp.MustCompile(`:(\d+)(?::\d+)?$`)
+
+// cutLine splits a location such as main.go:42:7 into the file and the line,
+// if the file exists and the location itself does not. The column is
+// dropped, as editors are opened at a line.
+func cutLine(target string) (string, int, bool) {
+	if _, err := os.Stat(target); err == nil {
+		return "", 0, false
+	}
+	match := lineSuffix.FindStringSubmatch(target)
+	if match == nil {
+		return "", 0, false
+	}
+	file := strings.TrimSuffix(target, match[0])
+	if _, err := os.Stat(file); err != nil {
+		return "", 0, false
+	}
+	line, err := strconv.Atoi(match[1])
+	return file, line, err == nil
 }
 
 func isDir(path string) (bool, error) {
 	fileInfo, err := os.Stat(path)
 	if err != nil {
-		return false, project.ErrInvalidPath
+		return false, project.PathError{Path: path, Err: err}
 	}
 
 	return fileInfo.IsDir(), nil
@@ -104,22 +426,57 @@ func isDir(path string) (bool, error) {
 func Execute() {
 	rootCmd.SilenceUsage = true
 	rootCmd.SilenceErrors = false
+	closeLog := openLog()
+	slog.Info("run", "args", os.Args[1:])
 	err := rootCmd.Execute()
 
 	if err == nil {
+		closeLog()
 		return
 	}
 
-	if errors.Is(err, ErrEditorEnvNotSet) {
-		rootCmd.PrintErrln(helpNoEditorConfigured)
+	slog.Error("failed", "err", err)
+	render(rootCmd.ErrOrStderr(), err, global.Verbose)
+	if !isTerminal(os.Stderr) {
+		// Without a valid configuration, the socket of the flag or the
+		// default one is used
+		config, _ := config.Load()
+		socket := tmux.Socket(cmp.Or(global.Socket, config.Socket))
+		notify(err, tmux.Cmd{Runner: runner.CmdRunner{}, Socket: socket, Client: global.Client})
 	}
+	closeLog()
+	os.Exit(1)
+}
 
-	var commandNotInstalled shell.NotInstalledError
-	if errors.As(err, &commandNotInstalled) {
-		rootCmd.PrintErrln(fmt.Sprintf(helpCommandNotInstalledTemplate, commandNotInstalled.Cmd))
+// openLog makes the log file the default logger, and returns the function
+// that closes it. tmuxide runs without a log if the log can't be opened.
+func openLog() func() {
+	// An invalid level is reported when the command loads the configuration
+	config, _ := config.Load()
+	level, _ := config.Level()
+	logger, closer, err := logging.Open(level)
+	if err != nil {
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+		return func() {}
 	}
+	slog.SetDefault(logger)
+	return func() { closer.Close() }
+}
 
-	os.Exit(1)
+// notify shows the error in the current tmux client. Without a terminal, as
+// when run from a tmux key binding, the error printed to stderr is not seen.
+func notify(err error, tmux tmux.Cmd) {
+	if os.Getenv("TMUX") == "" {
+		return
+	}
+	if err := tmux.DisplayMessage("ide: " + err.Error()); err != nil {
+		slog.Warn("could not show the error in tmux", "err", err)
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
 
 func editorCmd(path path.ShellPath) ([]string, error) {
@@ -132,8 +489,27 @@ func editorCmd(path path.ShellPath) ([]string, error) {
 	if !path.Contains(editorCmd[0]) {
 		return nil, ErrEditorNotInstalled
 	}
+	slog.Info("editor", "command", editorCmd)
 	return editorCmd, nil
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVarP(&global.Socket, "socket", "L", "", "name or path of the socket of the tmux server to use")
+	rootCmd.PersistentFlags().StringVar(&global.Client, "client", "", "tty of the tmux client to switch, instead of the current client")
+	rootCmd.PersistentFlags().BoolVarP(&global.Verbose, "verbose", "v", false, "print each command with how long it took, and the output of failed commands")
+	rootCmd.PersistentFlags().BoolVar(&global.DryRun, "dry-run", false, "print the commands that would change something instead of running them")
+	rootCmd.Flags().BoolVarP(&options.Detach, "detach", "d", false, "create the session without switching or attaching to it, and print its name")
+	rootCmd.Flags().BoolVar(&options.Detach, "no-attach", false, "same as --detach")
+	rootCmd.Flags().BoolVar(&options.JSON, "json", false, "print the detached session as JSON, implies --detach")
+	rootCmd.Flags().BoolVar(&options.Window, "window", false, "open in a new window of the current session")
+	rootCmd.Flags().StringVar(&options.Split, "split", "", "open in a split pane of the current session, side by side (h) or on top of each other (v)")
+	rootCmd.Flags().BoolVar(&options.Popup, "popup", false, "open in a popup on top of the current session")
+	rootCmd.Flags().BoolVar(&options.Create, "create", false, "create the target if it does not exist, or pick a new target by typing its path")
+	rootCmd.Flags().BoolVar(&options.Branch, "branch", false, "pick a branch of the repository and open the target in a worktree of that branch")
+	rootCmd.Flags().StringVarP(&options.Template, "template", "t", "", "name of the template to create a new session with")
+	rootCmd.MarkFlagsMutuallyExclusive("detach", "window", "split", "popup")
+	// --no-attach and --json detach too, so they go with --detach but not
+	// with the others
+	rootCmd.MarkFlagsMutuallyExclusive("no-attach", "window", "split", "popup")
+	rootCmd.MarkFlagsMutuallyExclusive("json", "window", "split", "popup")
 }
@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"bytes"
 	"errors"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/ide"
 	"github.com/eskelinenantti/tmuxide/internal/project"
 	"github.com/eskelinenantti/tmuxide/internal/shell"
 	"github.com/eskelinenantti/tmuxide/internal/test/mock"
@@ -15,6 +20,76 @@ import (
 
 const editor string = "editor"
 
+func TestMain(m *testing.M) {
+	// Keep the files of the user running the tests out of the tests
+	home, err := os.MkdirTemp("", "tmuxide")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+	os.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	os.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	createDir(t, configHome, "tmuxide")
+	if err := os.WriteFile(filepath.Join(configHome, "tmuxide", "config.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createDir(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const socketPath = "/tmp/tmux-1000/default"
+
+var displaySocketPath = []string{"tmux", "display-message", "-p", "#{socket_path}"}
+
+// setAttached sets $TMUX as if running inside a client of the server.
+func setAttached(t *testing.T) {
+	t.Helper()
+	t.Setenv("TMUX", socketPath+",1234,0")
+}
+
+// respondAttached pads the responses up to the given number of calls, and
+// answers the socket path query that follows with the socket of $TMUX.
+func respondAttached(responses []spy.Response, calls int) []spy.Response {
+	for len(responses) < calls {
+		responses = append(responses, spy.Response{})
+	}
+	return append(responses, spy.Response{OnRun: mock.WriteToStdout(socketPath)})
+}
+
+// fzfVersion is the call that checks whether fzf supports --tmux, answered
+// by respondFzfVersion.
+var fzfVersion = []string{"fzf", "--version"}
+var respondFzfVersion = spy.Response{OnRun: mock.WriteToStdout("0.56.3 (brew)\n")}
+
+// tmuxVersion is the call that checks which features tmux supports, answered
+// by respondTmuxVersion.
+var tmuxVersion = []string{"tmux", "-V"}
+var respondTmuxVersion = spy.Response{OnRun: mock.WriteToStdout("tmux 3.4\n")}
+
+// listPanes is the call that records the windows of the session.
+func listPanes(session string) []string {
+	return []string{"tmux", "list-panes", "-s", "-t", session + ":", "-F", "#{window_index}\t#{window_active}\t#{window_layout}\t#{pane_current_path}\t#{window_name}"}
+}
+
 func unsetenv(t *testing.T, key string) {
 	t.Helper()
 	t.Setenv(key, "")
@@ -59,7 +134,7 @@ func TestSelectFolderFromPrompt(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Setenv("EDITOR", editor)
 			if tt.attached {
-				t.Setenv("TMUX", "test")
+				setAttached(t)
 			} else {
 				unsetenv(t, "TMUX")
 			}
@@ -73,24 +148,29 @@ func TestSelectFolderFromPrompt(t *testing.T) {
 
 			spyRunner := &spy.SpyRunner{
 				Responses: []spy.Response{
+					respondFzfVersion,
 					{OnRun: mock.WriteToStdout(folder)},
 				},
 			}
-			err := Ide([]string{}, spyRunner, mock.Path{})
-			requireNoError(t, err)
 
 			session := project.Name(filepath.Join(home, folder))
 			expectedCalls := [][]string{
+				fzfVersion,
 				{"fzf", "--reverse", "--height", "70%", "--tmux", "70%"},
 				{"fd", "--follow", "--hidden", "--exclude", "{.git,node_modules,Library}", ".", "--base-directory", home},
 				{"tmux", "has-session", "-t", session + ":"},
 			}
+			expectedCalls = append(expectedCalls, listPanes(session))
 			if tt.attached {
-				expectedCalls = append(expectedCalls, []string{"tmux", "switch-client", "-t", session + ":"})
+				spyRunner.Responses = respondAttached(spyRunner.Responses, len(expectedCalls))
+				expectedCalls = append(expectedCalls, displaySocketPath, []string{"tmux", "switch-client", "-t", session + ":"})
 			} else {
 				expectedCalls = append(expectedCalls, []string{"tmux", "attach", "-t", session + ":"})
 			}
 
+			err := Ide([]string{}, Options{}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
 			requireCalls(t, expectedCalls, spyRunner.Calls)
 		})
 	}
@@ -111,7 +191,7 @@ func TestFolderSessionWorkflow(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Setenv("EDITOR", editor)
 			if tt.attached {
-				t.Setenv("TMUX", "test")
+				setAttached(t)
 			} else {
 				unsetenv(t, "TMUX")
 			}
@@ -130,21 +210,23 @@ func TestFolderSessionWorkflow(t *testing.T) {
 				spyRunner.Responses = []spy.Response{{OnRun: mock.SimulateError}}
 			}
 
-			err := Ide([]string{dir}, spyRunner, mock.Path{})
-			requireNoError(t, err)
-
 			expectedCalls := [][]string{
 				{"tmux", "has-session", "-t", session + ":"},
 			}
 			if !tt.sessionExists {
 				expectedCalls = append(expectedCalls, []string{"tmux", "new-session", "-c", dir, "-d", "-s", session})
 			}
+			expectedCalls = append(expectedCalls, listPanes(session))
 			if tt.attached {
-				expectedCalls = append(expectedCalls, []string{"tmux", "switch-client", "-t", session + ":"})
+				spyRunner.Responses = respondAttached(spyRunner.Responses, len(expectedCalls))
+				expectedCalls = append(expectedCalls, displaySocketPath, []string{"tmux", "switch-client", "-t", session + ":"})
 			} else {
 				expectedCalls = append(expectedCalls, []string{"tmux", "attach", "-t", session + ":"})
 			}
 
+			err := Ide([]string{dir}, Options{}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
 			requireCalls(t, expectedCalls, spyRunner.Calls)
 		})
 	}
@@ -156,7 +238,7 @@ func TestTmuxNotInstalled(t *testing.T) {
 
 	spyRunner := &spy.SpyRunner{}
 
-	err := Ide([]string{}, spyRunner, mock.Path{Missing: []string{"tmux"}})
+	err := Ide([]string{}, Options{}, spyRunner, mock.Path{Missing: []string{"tmux"}})
 
 	expectedError := shell.NotInstalledError{Cmd: "tmux"}
 	var cmdNotInstalledError shell.NotInstalledError
@@ -186,7 +268,7 @@ func TestEditorSessionWorkflow(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Setenv("EDITOR", editor)
 			if tt.attached {
-				t.Setenv("TMUX", "test")
+				setAttached(t)
 			} else {
 				unsetenv(t, "TMUX")
 			}
@@ -204,9 +286,6 @@ func TestEditorSessionWorkflow(t *testing.T) {
 			}
 			spyRunner := &spy.SpyRunner{Responses: responses}
 
-			err := Ide([]string{file}, spyRunner, mock.Path{})
-			requireNoError(t, err)
-
 			expectedCalls := [][]string{
 				{"git", "-C", dir, "rev-parse", "--show-toplevel"},
 				{"tmux", "has-session", "-t", session + ":" + editor},
@@ -221,12 +300,17 @@ func TestEditorSessionWorkflow(t *testing.T) {
 					[]string{"tmux", "new-session", "-c", dir, "-d", "-s", session, editor, file},
 				)
 			}
+			expectedCalls = append(expectedCalls, listPanes(session))
 			if tt.attached {
-				expectedCalls = append(expectedCalls, []string{"tmux", "switch-client", "-t", session + ":"})
+				spyRunner.Responses = respondAttached(spyRunner.Responses, len(expectedCalls))
+				expectedCalls = append(expectedCalls, displaySocketPath, []string{"tmux", "switch-client", "-t", session + ":"})
 			} else {
 				expectedCalls = append(expectedCalls, []string{"tmux", "attach", "-t", session + ":"})
 			}
 
+			err := Ide([]string{file}, Options{}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
 			requireCalls(t, expectedCalls, spyRunner.Calls)
 		})
 	}
@@ -252,7 +336,7 @@ func TestRelativePathToFile(t *testing.T) {
 		},
 	}
 
-	err := Ide([]string{fileName}, spyRunner, mock.Path{})
+	err := Ide([]string{fileName}, Options{}, spyRunner, mock.Path{})
 	requireNoError(t, err)
 
 	expectedCalls := [][]string{
@@ -260,6 +344,7 @@ func TestRelativePathToFile(t *testing.T) {
 		{"tmux", "has-session", "-t", session + ":" + editor},
 		{"tmux", "has-session", "-t", session + ":"},
 		{"tmux", "new-session", "-c", ".", "-d", "-s", session, editor, fileName},
+		listPanes(session),
 		{"tmux", "attach", "-t", session + ":"},
 	}
 
@@ -275,7 +360,7 @@ func TestFileDoesNotExist(t *testing.T) {
 
 	spyRunner := &spy.SpyRunner{}
 
-	err := Ide([]string{file}, spyRunner, mock.Path{})
+	err := Ide([]string{file}, Options{}, spyRunner, mock.Path{})
 
 	if !errors.Is(err, project.ErrInvalidPath) {
 		t.Fatalf("got=%v, want=%v", err, project.ErrInvalidPath)
@@ -301,7 +386,7 @@ func TestFileInRepository(t *testing.T) {
 		},
 	}
 
-	err := Ide([]string{file}, spyRunner, mock.Path{})
+	err := Ide([]string{file}, Options{}, spyRunner, mock.Path{})
 	requireNoError(t, err)
 
 	expectedCalls := [][]string{
@@ -309,6 +394,7 @@ func TestFileInRepository(t *testing.T) {
 		{"tmux", "has-session", "-t", session + ":" + editor},
 		{"tmux", "has-session", "-t", session + ":"},
 		{"tmux", "new-session", "-c", repository, "-d", "-s", session, editor, file},
+		listPanes(session),
 		{"tmux", "attach", "-t", session + ":"},
 	}
 
@@ -323,7 +409,7 @@ func TestEditorNotSet(t *testing.T) {
 
 	spyRunner := &spy.SpyRunner{}
 
-	err := Ide([]string{dir}, spyRunner, mock.Path{})
+	err := Ide([]string{dir}, Options{}, spyRunner, mock.Path{})
 
 	if !errors.Is(err, ErrEditorEnvNotSet) {
 		t.Fatalf("got=%v, want=%v", err, ErrEditorEnvNotSet)
@@ -340,9 +426,277 @@ func TestEditorNotInstalled(t *testing.T) {
 
 	spyRunner := &spy.SpyRunner{}
 
-	err := Ide([]string{dir}, spyRunner, &mockPath)
+	err := Ide([]string{dir}, Options{}, spyRunner, &mockPath)
 	if !errors.Is(err, ErrEditorNotInstalled) {
 		t.Fatalf("got=%v, want=%v", err, ErrEditorNotInstalled)
 	}
 	requireCalls(t, nil, spyRunner.Calls)
 }
+
+func TestDetach(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		output  func(dir, session string) string
+	}{
+		{
+			name:    "prints session name",
+			options: Options{Detach: true},
+			output:  func(dir, session string) string { return session + "\n" },
+		},
+		{
+			name:    "prints session as json",
+			options: Options{JSON: true},
+			output: func(dir, session string) string {
+				return `{"name":"` + session + `","dir":"` + dir + `","window":"` + editor + `"}` + "\n"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			setAttached(t)
+
+			dir := t.TempDir()
+			file := createFile(t, dir, "file.txt")
+			session := project.Name(dir)
+
+			spyRunner := &spy.SpyRunner{
+				Responses: []spy.Response{
+					{OnRun: mock.WriteToStdout(dir)},
+					{OnRun: mock.SimulateError},
+					{OnRun: mock.SimulateError},
+				},
+			}
+
+			var output bytes.Buffer
+			tt.options.Output = &output
+			err := Ide([]string{file}, tt.options, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			expectedCalls := [][]string{
+				{"git", "-C", dir, "rev-parse", "--show-toplevel"},
+				{"tmux", "has-session", "-t", session + ":" + editor},
+				{"tmux", "has-session", "-t", session + ":"},
+				{"tmux", "new-session", "-c", dir, "-d", "-s", session, editor, file},
+				listPanes(session),
+			}
+			requireCalls(t, expectedCalls, spyRunner.Calls)
+
+			if diff := cmp.Diff(tt.output(dir, session), output.String()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestOpenInCurrentSession(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		file    bool
+		probe   bool
+		want    func(dir, file string) []string
+	}{
+		{
+			name:    "opens file in window",
+			options: Options{Window: true},
+			file:    true,
+			want: func(dir, file string) []string {
+				return []string{"tmux", "new-window", "-c", dir, "-n", editor, editor, file}
+			},
+		},
+		{
+			name:    "opens folder in window",
+			options: Options{Window: true},
+			want: func(dir, file string) []string {
+				return []string{"tmux", "new-window", "-c", dir}
+			},
+		},
+		{
+			name:    "opens file in horizontal split",
+			options: Options{Split: "h"},
+			file:    true,
+			want: func(dir, file string) []string {
+				return []string{"tmux", "split-window", "-c", dir, "-h", editor, file}
+			},
+		},
+		{
+			name:    "opens folder in vertical split",
+			options: Options{Split: "v"},
+			want: func(dir, file string) []string {
+				return []string{"tmux", "split-window", "-c", dir, "-v"}
+			},
+		},
+		{
+			name:    "opens file in popup",
+			options: Options{Popup: true},
+			file:    true,
+			probe:   true,
+			want: func(dir, file string) []string {
+				return []string{"tmux", "display-popup", "-d", dir, "-E", editor, file}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			setAttached(t)
+
+			dir := t.TempDir()
+			target := dir
+			spyRunner := &spy.SpyRunner{}
+			var expectedCalls [][]string
+			if tt.file {
+				target = createFile(t, dir, "file.txt")
+				spyRunner.Responses = []spy.Response{{OnRun: mock.WriteToStdout(dir)}}
+				expectedCalls = append(expectedCalls, []string{"git", "-C", dir, "rev-parse", "--show-toplevel"})
+			}
+
+			spyRunner.Responses = respondAttached(spyRunner.Responses, len(expectedCalls))
+			expectedCalls = append(expectedCalls, displaySocketPath)
+			if tt.probe {
+				expectedCalls = append(expectedCalls, tmuxVersion)
+			}
+			expectedCalls = append(expectedCalls, tt.want(dir, target))
+
+			err := Ide([]string{target}, tt.options, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			requireCalls(t, expectedCalls, spyRunner.Calls)
+		})
+	}
+}
+
+func TestOpenInCurrentSessionWhenNotAttached(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+
+	spyRunner := &spy.SpyRunner{}
+
+	err := Ide([]string{t.TempDir()}, Options{Popup: true}, spyRunner, mock.Path{})
+	if !errors.Is(err, ide.ErrNotAttached) {
+		t.Fatalf("got=%v, want=%v", err, ide.ErrNotAttached)
+	}
+	requireCalls(t, nil, spyRunner.Calls)
+}
+
+func TestInvalidSplit(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	setAttached(t)
+
+	spyRunner := &spy.SpyRunner{}
+
+	err := Ide([]string{t.TempDir()}, Options{Split: "x"}, spyRunner, mock.Path{})
+	if !errors.Is(err, ErrInvalidSplit) {
+		t.Fatalf("got=%v, want=%v", err, ErrInvalidSplit)
+	}
+	requireCalls(t, nil, spyRunner.Calls)
+}
+
+func TestSocket(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		global Global
+		want   []string
+	}{
+		{name: "uses server of current client"},
+		{name: "uses socket name from flag", global: Global{Socket: "work"}, want: []string{"-L", "work"}},
+		{name: "uses socket path from flag", global: Global{Socket: "/tmp/tmux/work"}, want: []string{"-S", "/tmp/tmux/work"}},
+		{name: "uses socket from config", config: `{"socket": "personal"}`, want: []string{"-L", "personal"}},
+		{name: "prefers flag over config", config: `{"socket": "personal"}`, global: Global{Socket: "work"}, want: []string{"-L", "work"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			setAttached(t)
+			if tt.config != "" {
+				writeConfig(t, tt.config)
+			}
+
+			dir := t.TempDir()
+			session := project.Name(dir)
+
+			spyRunner := &spy.SpyRunner{Responses: respondAttached(nil, 2)}
+			err := Ide([]string{dir}, Options{Global: tt.global}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			tmux := append([]string{"tmux"}, tt.want...)
+			expectedCalls := [][]string{
+				append(slices.Clone(tmux), "has-session", "-t", session+":"),
+				append(slices.Clone(tmux), listPanes(session)[1:]...),
+				append(slices.Clone(tmux), "display-message", "-p", "#{socket_path}"),
+				append(slices.Clone(tmux), "switch-client", "-t", session+":"),
+			}
+			requireCalls(t, expectedCalls, spyRunner.Calls)
+		})
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	writeConfig(t, `{"socket": `)
+
+	spyRunner := &spy.SpyRunner{}
+	err := Ide([]string{t.TempDir()}, Options{}, spyRunner, mock.Path{})
+	if !errors.Is(err, config.ErrInvalidConfig) {
+		t.Fatalf("got=%v, want=%v", err, config.ErrInvalidConfig)
+	}
+	requireCalls(t, nil, spyRunner.Calls)
+}
+
+func TestClientOfAnotherServer(t *testing.T) {
+	tests := []struct {
+		name   string
+		global Global
+		server string
+		calls  func(session string) [][]string
+	}{
+		{
+			name:   "attaches when client belongs to another server",
+			server: "/tmp/tmux-1000/work",
+			calls: func(session string) [][]string {
+				return [][]string{
+					listPanes(session),
+					displaySocketPath,
+					{"tmux", "attach", "-t", session + ":"},
+				}
+			},
+		},
+		{
+			name:   "switches given client",
+			global: Global{Client: "/dev/pts/3"},
+			calls: func(session string) [][]string {
+				return [][]string{
+					listPanes(session),
+					{"tmux", "switch-client", "-c", "/dev/pts/3", "-t", session + ":"},
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			setAttached(t)
+
+			dir := t.TempDir()
+			session := project.Name(dir)
+
+			spyRunner := &spy.SpyRunner{}
+			if tt.server != "" {
+				spyRunner.Responses = []spy.Response{{}, {}, {OnRun: mock.WriteToStdout(tt.server)}}
+			}
+
+			err := Ide([]string{dir}, Options{Global: tt.global}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			expectedCalls := append([][]string{{"tmux", "has-session", "-t", session + ":"}}, tt.calls(session)...)
+			requireCalls(t, expectedCalls, spyRunner.Calls)
+		})
+	}
+}
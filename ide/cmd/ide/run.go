@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/eskelinenantti/tmuxide/internal/ide"
+	"github.com/eskelinenantti/tmuxide/internal/shell/path"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <file|folder> -- <command> [args...]",
+	Short: "Run a command in the session of a file or folder.",
+	Long: `Run a command in the session of a file or folder.
+
+The session is resolved the same way as when opening the file or folder, and
+it is created if it does not exist yet. The command runs in its own window,
+which is named after the command unless --name is given. Arguments are passed
+to the command as is, without being interpreted by a shell.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() != 1 || len(args) < 2 {
+			return ErrRunUsage
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		options := runOptions
+		options.Global = global
+		return Run(args[0], args[1:], options, commandRunner(global, cmd), path.Path{})
+	},
+}
+
+var ErrRunUsage = errors.New("expected a file or folder followed by -- and the command to run")
+
+type RunOptions struct {
+	Global
+	// Window is the name of the window the command runs in.
+	Window string
+	// Replace kills the window and runs the command again if it already exists.
+	Replace bool
+	// NoSwitch leaves the current client as is instead of switching to the session.
+	NoSwitch bool
+}
+
+var runOptions RunOptions
+
+func Run(target string, command []string, options RunOptions, runner runner.Runner, path path.ShellPath) error {
+	shell, config, err := setup(options.Global, runner, path)
+	if err != nil {
+		return err
+	}
+
+	proj, _, _, err := resolve(target, shell, config)
+	if err != nil {
+		return err
+	}
+
+	proj, err = applyTemplate(proj, "", config.Templates, shell.Git)
+	if err != nil {
+		return err
+	}
+
+	window, err := ide.Run(command, tmux.WindowName(options.Window), options.Replace, proj, shell.Tmux)
+	if err != nil {
+		return err
+	}
+
+	if !options.DryRun {
+		if err := record(proj, target, commands(window, command), shell.Tmux); err != nil {
+			return err
+		}
+	}
+	if options.NoSwitch {
+		return nil
+	}
+
+	return ide.Open(proj, shell.Tmux)
+}
+
+func init() {
+	runCmd.Flags().StringVarP(&runOptions.Window, "name", "n", "", "name of the window to run the command in")
+	runCmd.Flags().BoolVarP(&runOptions.Replace, "replace", "r", false, "replace the window if it already exists instead of reusing it")
+	runCmd.Flags().BoolVar(&runOptions.NoSwitch, "no-switch", false, "do not switch to or attach to the session")
+	rootCmd.AddCommand(runCmd)
+}
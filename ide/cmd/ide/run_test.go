@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+)
+
+func TestRunWorkflow(t *testing.T) {
+	tests := []struct {
+		name          string
+		command       []string
+		options       RunOptions
+		windowExists  bool
+		sessionExists bool
+		window        string
+	}{
+		{name: "creates session", command: []string{"make", "test"}, window: "make"},
+		{name: "creates window", command: []string{"make", "test"}, sessionExists: true, window: "make"},
+		{name: "reuses window", command: []string{"make", "test"}, windowExists: true, window: "make"},
+		{name: "replaces window", command: []string{"make", "test"}, options: RunOptions{Replace: true}, windowExists: true, window: "make"},
+		{name: "names window after shell command", command: []string{"sh", "-c", "go test ./... && echo 'done'"}, window: "go"},
+		{name: "names window after program", command: []string{"env", "CI=1", "./scripts/lint.sh"}, window: "lint_sh"},
+		{name: "uses given window name", command: []string{"make", "test"}, options: RunOptions{Window: "tests"}, window: "tests"},
+		{name: "sanitizes given window name", command: []string{"make", "test"}, options: RunOptions{Window: "unit.tests"}, window: "unit_tests"},
+		{name: "does not switch", command: []string{"make"}, options: RunOptions{NoSwitch: true}, window: "make"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setAttached(t)
+
+			dir := t.TempDir()
+			session := project.Name(dir)
+
+			var responses []spy.Response
+			if !tt.windowExists {
+				responses = append(responses, spy.Response{OnRun: mock.SimulateError})
+				if !tt.sessionExists {
+					responses = append(responses, spy.Response{OnRun: mock.SimulateError})
+				}
+			}
+			spyRunner := &spy.SpyRunner{Responses: responses}
+
+			expectedCalls := [][]string{
+				{"tmux", "has-session", "-t", session + ":" + tt.window},
+			}
+			switch {
+			case tt.windowExists && tt.options.Replace:
+				expectedCalls = append(expectedCalls, append([]string{"tmux", "new-window", "-t", session + ":" + tt.window, "-c", dir, "-k", "-n", tt.window}, tt.command...))
+			case tt.windowExists:
+				expectedCalls = append(expectedCalls, []string{"tmux", "select-window", "-t", session + ":" + tt.window})
+			case tt.sessionExists:
+				expectedCalls = append(expectedCalls,
+					[]string{"tmux", "has-session", "-t", session + ":"},
+					append([]string{"tmux", "new-window", "-t", session + ":", "-c", dir, "-k", "-n", tt.window}, tt.command...),
+				)
+			default:
+				expectedCalls = append(expectedCalls,
+					[]string{"tmux", "has-session", "-t", session + ":"},
+					append([]string{"tmux", "new-session", "-c", dir, "-d", "-s", session, "-n", tt.window}, tt.command...),
+				)
+			}
+			expectedCalls = append(expectedCalls, listPanes(session))
+			if !tt.options.NoSwitch {
+				spyRunner.Responses = respondAttached(spyRunner.Responses, len(expectedCalls))
+				expectedCalls = append(expectedCalls, displaySocketPath, []string{"tmux", "switch-client", "-t", session + ":"})
+			}
+
+			err := Run(dir, tt.command, tt.options, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			requireCalls(t, expectedCalls, spyRunner.Calls)
+		})
+	}
+}
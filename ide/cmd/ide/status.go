@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/layout"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/git"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+	"github.com/eskelinenantti/tmuxide/internal/shell/version"
+	"github.com/eskelinenantti/tmuxide/internal/xdg"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status <session>",
+	Short: "Print the project of a session for the tmux status line.",
+	Long: `Print the project of a tmuxide session for the tmux status line: the shortened
+project root and the git branch, with the number of changed files and the
+number of commits ahead and behind of the upstream branch, e.g.
+
+  ~/s/g/owner/tmuxide main *2 ↑1 ↓3
+
+To show it in the status line, add this to tmux.conf:
+
+  set -g status-right '#(ide status #{session_name})'
+
+Nothing is printed for sessions not created by tmuxide. tmux runs the command
+whenever it redraws the status line, so the status is cached for a few
+seconds.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		options := statusOptions
+		options.Global = global
+		options.Output = cmd.OutOrStdout()
+		return Status(args[0], options, commandRunner(global, cmd))
+	},
+}
+
+// statusTTL is how long the status of a session is cached.
+const statusTTL = 5 * time.Second
+
+type StatusOptions struct {
+	Global
+	// Toolchain adds the toolchain of the project, e.g. go or node, detected
+	// the same way as the templates of new sessions.
+	Toolchain bool
+	Output    io.Writer
+}
+
+var statusOptions StatusOptions
+
+// toolchains are detected after the templates of the configuration.
+var toolchains = []config.Template{
+	{Name: "go", Markers: []string{"go.mod"}},
+	{Name: "rust", Markers: []string{"Cargo.toml"}},
+	{Name: "node", Markers: []string{"package.json"}},
+	{Name: "python", Markers: []string{"pyproject.toml", "requirements.txt"}},
+	{Name: "ruby", Markers: []string{"Gemfile"}},
+	{Name: "java", Markers: []string{"pom.xml", "build.gradle", "build.gradle.kts"}},
+}
+
+func Status(session string, options StatusOptions, runner runner.Runner) error {
+	name := session
+	if options.Toolchain {
+		name += ".toolchain"
+	}
+	cache := filepath.Join(xdg.CacheHome(), "status", url.PathEscape(name))
+	if info, err := os.Stat(cache); err == nil && time.Since(info.ModTime()) < statusTTL {
+		if cached, err := os.ReadFile(cache); err == nil {
+			_, err = options.Output.Write(cached)
+			return err
+		}
+	}
+
+	status, err := sessionStatus(session, options, runner)
+	if err != nil {
+		// Errors would be shown in the client on every redraw of the status
+		// line, so the status is left empty until the cache expires
+		slog.Warn("status", "error", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cache), 0755); err == nil {
+		// Failing to cache the status only makes the next status slower
+		_ = os.WriteFile(cache, []byte(status), 0644)
+	}
+	_, err = io.WriteString(options.Output, status)
+	return err
+}
+
+// sessionStatus returns the status line of the session, which is empty for
+// sessions not created by tmuxide. Only tmux and git are needed, unlike for
+// opening projects.
+func sessionStatus(session string, options StatusOptions, runner runner.Runner) (string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	server := tmux.Cmd{
+		Runner:   runner,
+		Versions: version.Cmd{Runner: runner, Cache: &version.Cache{}},
+		Socket:   tmux.Socket(cmp.Or(options.Socket, cfg.Socket)),
+		Client:   options.Client,
+	}
+
+	sessions, err := server.ListSessions()
+	if err != nil {
+		return "", err
+	}
+
+	i := slices.IndexFunc(sessions, func(s tmux.Session) bool { return s.Name == session })
+	switch {
+	case i < 0:
+		return "", nil
+	case sessions[i].Remote != "":
+		// The repository is on the remote host, out of reach of git
+		return sessions[i].Remote + "\n", nil
+	case !project.IsSession(session, sessions[i].Path):
+		return "", nil
+	}
+
+	var templates []config.Template
+	if options.Toolchain {
+		templates = slices.Concat(cfg.Templates, toolchains)
+	}
+	return projectStatus(sessions[i].Path, git.Cmd{Runner: runner}, templates) + "\n", nil
+}
+
+// projectStatus returns the status of the project in the directory. The
+// toolchain is the first of the templates detected in the directory.
+func projectStatus(dir string, git git.Cmd, templates []config.Template) string {
+	parts := []string{shorten(dir)}
+
+	// Projects outside git repositories have no branch
+	if status, err := git.Status(dir); err == nil {
+		branch := status.Branch
+		if status.Changed > 0 {
+			branch += fmt.Sprintf(" *%d", status.Changed)
+		}
+		if status.Ahead > 0 {
+			branch += fmt.Sprintf(" ↑%d", status.Ahead)
+		}
+		if status.Behind > 0 {
+			branch += fmt.Sprintf(" ↓%d", status.Behind)
+		}
+		parts = append(parts, branch)
+	}
+
+	if toolchain, ok := layout.Detect(templates, dir); ok {
+		parts = append(parts, toolchain.Name)
+	}
+	return strings.Join(parts, " ")
+}
+
+// shorten abbreviates the home directory with ~, and the directories before
+// the last two to their first letters, e.g. ~/s/g/owner/repository.
+func shorten(dir string) string {
+	if home := os.Getenv("HOME"); home != "" {
+		if rest, ok := strings.CutPrefix(dir, home); ok && (rest == "" || rest[0] == filepath.Separator) {
+			dir = "~" + rest
+		}
+	}
+
+	parts := strings.Split(dir, string(filepath.Separator))
+	for i := 0; i < len(parts)-2; i++ {
+		parts[i] = abbreviateDir(parts[i])
+	}
+	return strings.Join(parts, string(filepath.Separator))
+}
+
+// abbreviateDir returns the first letter of the directory, keeping the dot of
+// hidden directories.
+func abbreviateDir(dir string) string {
+	prefix, rest := "", dir
+	if strings.HasPrefix(dir, ".") && len(dir) > 1 {
+		prefix, rest = ".", dir[1:]
+	}
+	if rest == "" {
+		return dir
+	}
+	_, size := utf8.DecodeRuneInString(rest)
+	return prefix + rest[:size]
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusOptions.Toolchain, "toolchain", false, "add the toolchain of the project, e.g. go or node")
+	rootCmd.AddCommand(statusCmd)
+}
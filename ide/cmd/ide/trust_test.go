@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+)
+
+func trustProject(t *testing.T, dir string) {
+	t.Helper()
+	var out bytes.Buffer
+	requireNoError(t, Trust(dir, Global{}, &out))
+}
+
+func TestTrust(t *testing.T) {
+	const projectConfig = `{"env": {"STAGE": "dev"}, "env_files": [".env"]}`
+
+	tests := []struct {
+		name       string
+		trust      bool
+		changed    bool
+		envChanged bool
+		env        []string
+		warning    string
+	}{
+		{
+			name:    "ignores untrusted config",
+			warning: "which has not been trusted",
+		},
+		{
+			name:  "applies trusted config",
+			trust: true,
+			env:   []string{"-e", "STAGE=dev", "-e", "TOKEN=abc"},
+		},
+		{
+			name:    "ignores config changed after trusting",
+			trust:   true,
+			changed: true,
+			warning: "which has changed since it was trusted",
+		},
+		{
+			name:       "ignores config with env file changed after trusting",
+			trust:      true,
+			envChanged: true,
+			warning:    "which has changed since it was trusted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			t.Setenv("XDG_DATA_HOME", t.TempDir())
+			unsetenv(t, "TMUX")
+
+			dir := t.TempDir()
+			writeFile(t, dir, config.ProjectFile, projectConfig)
+			writeFile(t, dir, ".env", "TOKEN=abc\n")
+			if tt.trust {
+				trustProject(t, dir)
+			}
+			if tt.changed {
+				writeFile(t, dir, config.ProjectFile, `{"env": {"STAGE": "prod"}}`)
+			}
+			if tt.envChanged {
+				writeFile(t, dir, ".env", "LD_PRELOAD=/tmp/evil.so\n")
+			}
+			session := project.Name(dir)
+
+			spyRunner := &spy.SpyRunner{Responses: []spy.Response{{OnRun: mock.SimulateError}}}
+			var stderr bytes.Buffer
+			err := Ide([]string{dir}, Options{Global: Global{Stderr: &stderr}}, spyRunner, mock.Path{})
+			requireNoError(t, err)
+
+			if tt.warning == "" && stderr.Len() > 0 {
+				t.Errorf("got warning=%q, want none", stderr.String())
+			}
+			warning := "Ignoring " + filepath.Join(dir, config.ProjectFile) + ", " + tt.warning
+			if tt.warning != "" && !strings.HasPrefix(stderr.String(), warning) {
+				t.Errorf("got warning=%q, want %q", stderr.String(), warning)
+			}
+
+			expectedCalls := [][]string{{"tmux", "has-session", "-t", session + ":"}}
+			if tt.env != nil {
+				expectedCalls = append(expectedCalls, tmuxVersion)
+			}
+			newSession := append([]string{"tmux", "new-session", "-c", dir, "-d"}, tt.env...)
+			expectedCalls = append(expectedCalls,
+				append(newSession, "-s", session),
+				listPanes(session),
+				[]string{"tmux", "attach", "-t", session + ":"},
+			)
+			requireCalls(t, expectedCalls, spyRunner.Calls)
+		})
+	}
+}
+
+func TestTrustFile(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, dir, config.ProjectFile, `{}`)
+	file := filepath.Join(dir, config.ProjectFile)
+
+	var out bytes.Buffer
+	err := Trust(file, Global{}, &out)
+	requireNoError(t, err)
+
+	if got, want := out.String(), "Trusted "+file+"\n"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestTrustMissingConfig(t *testing.T) {
+	var out bytes.Buffer
+	err := Trust(t.TempDir(), Global{}, &out)
+	if !os.IsNotExist(err) {
+		t.Fatalf("got=%v, want not exist error", err)
+	}
+}
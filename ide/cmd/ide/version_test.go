@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/test/mock"
+	"github.com/eskelinenantti/tmuxide/internal/test/spy"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOldFzf(t *testing.T) {
+	tests := []struct {
+		name    string
+		missing []string
+		want    []string
+	}{
+		{
+			name: "opens fzf-tmux",
+			want: []string{"fzf-tmux", "-d", "70%", "--", "--reverse"},
+		},
+		{
+			name:    "opens fzf inline without fzf-tmux",
+			missing: []string{"fzf-tmux"},
+			want:    []string{"fzf", "--reverse", "--height", "70%"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", editor)
+			unsetenv(t, "TMUX")
+
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			folder := "session"
+			if err := os.Mkdir(filepath.Join(home, folder), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+				{OnRun: mock.WriteToStdout("0.44.1 (debian)\n")},
+				{OnRun: mock.WriteToStdout(folder)},
+			}}
+			err := Ide([]string{}, Options{}, spyRunner, mock.Path{Missing: tt.missing})
+			requireNoError(t, err)
+
+			requireCalls(t, [][]string{fzfVersion, tt.want}, spyRunner.Calls[:2])
+		})
+	}
+}
+
+func TestOldTmuxPopup(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	setAttached(t)
+
+	dir := t.TempDir()
+	file := createFile(t, dir, "file.txt")
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.WriteToStdout(dir)},
+		{OnRun: mock.WriteToStdout(socketPath)},
+		{OnRun: mock.WriteToStdout("tmux 3.1c\n")},
+	}}
+	err := Ide([]string{file}, Options{Popup: true}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"git", "-C", dir, "rev-parse", "--show-toplevel"},
+		displaySocketPath,
+		tmuxVersion,
+		{"tmux", "new-window", "-c", dir, "-n", editor, editor, file},
+	}, spyRunner.Calls)
+}
+
+func TestOldTmuxEnvironment(t *testing.T) {
+	t.Setenv("EDITOR", editor)
+	unsetenv(t, "TMUX")
+
+	dir := t.TempDir()
+	writeFile(t, dir, ".tmuxide.json", `{"env": {"PORT": "8080", "STAGE": "dev"}}`)
+	trustProject(t, dir)
+	session := project.Name(dir)
+
+	spyRunner := &spy.SpyRunner{Responses: []spy.Response{
+		{OnRun: mock.SimulateError},
+		{OnRun: mock.WriteToStdout("tmux 3.0a\n")},
+	}}
+	err := Ide([]string{dir}, Options{}, spyRunner, mock.Path{})
+	requireNoError(t, err)
+
+	requireCalls(t, [][]string{
+		{"tmux", "has-session", "-t", session + ":"},
+		tmuxVersion,
+		{"tmux", "new-session", "-c", dir, "-d", "-s", session, "env", "PORT=8080", "STAGE=dev", "sh", "-c", `exec "${SHELL:-sh}" -l`},
+		{"tmux", "set-environment", "-t", session + ":", "PORT", "8080"},
+		{"tmux", "set-environment", "-t", session + ":", "STAGE", "dev"},
+		listPanes(session),
+		{"tmux", "attach", "-t", session + ":"},
+	}, spyRunner.Calls)
+}
+
+func TestRedactEnvironment(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{"tmux", "new-session", "-e", "TOKEN=secret", "-s", "api"},
+			want: []string{"tmux", "new-session", "-e", "TOKEN=***", "-s", "api"},
+		},
+		{
+			args: []string{"tmux", "new-session", "-s", "api", "env", "TOKEN=secret", "vim", "a=b"},
+			want: []string{"tmux", "new-session", "-s", "api", "env", "TOKEN=***", "vim", "a=b"},
+		},
+		{
+			args: []string{"tmux", "-L", "work", "set-environment", "-t", "api:", "TOKEN", "secret"},
+			want: []string{"tmux", "-L", "work", "set-environment", "-t", "api:", "TOKEN", "***"},
+		},
+		{
+			args: []string{"tmux", "set-environment", "-u", "-t", "api:", "TOKEN"},
+			want: []string{"tmux", "set-environment", "-u", "-t", "api:", "TOKEN"},
+		},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, runner.Redact(tt.args)); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eskelinenantti/tmuxide/internal/xdg"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// FileError tells which configuration file could not be parsed, such as the
+// global configuration or the configuration of a project.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("%s: %v: %v", e.Path, ErrInvalidConfig, e.Err)
+}
+
+func (e FileError) Unwrap() []error {
+	return []error{ErrInvalidConfig, e.Err}
+}
+
+type Config struct {
+	// Socket is the name or path of the socket of the tmux server to use.
+	Socket string `json:"socket"`
+	// Sockets are the tmux servers listed in addition to the default one when
+	// listing sessions from all servers.
+	Sockets []string `json:"sockets"`
+	// Hosts are the remote hosts whose files and folders are listed in the
+	// picker.
+	Hosts []string `json:"hosts"`
+	// CloneRoot is the directory repositories are cloned to, in subdirectories
+	// by host and path. Defaults to ~/src.
+	CloneRoot string `json:"clone_root"`
+	// Ssh is the command used to connect to remote hosts instead of ssh.
+	Ssh string `json:"ssh"`
+	// Templates are the windows created in new sessions by project type.
+	Templates []Template `json:"templates"`
+	// Hooks are run for every project, before the hooks of the project.
+	Hooks Hooks `json:"hooks"`
+	// HookTimeout is how long a hook may run, e.g. "1m". Defaults to 30s.
+	HookTimeout string `json:"hook_timeout"`
+	// LogLevel is the lowest level logged, one of "debug", "info", "warn" and
+	// "error". Defaults to "info".
+	LogLevel string `json:"log_level"`
+}
+
+// Hooks are shell scripts run at the events of project sessions.
+type Hooks struct {
+	// Create hooks run after a session has been created.
+	Create []string `json:"create"`
+	// Switch hooks run before switching or attaching to a session.
+	Switch []string `json:"switch"`
+	// Kill hooks run before a session is killed.
+	Kill []string `json:"kill"`
+}
+
+// Template lists the windows to create in new sessions of projects that
+// contain any of the marker files.
+type Template struct {
+	Name    string   `json:"name"`
+	Markers []string `json:"markers"`
+	Windows []Window `json:"windows"`
+}
+
+type Window struct {
+	Name string `json:"name"`
+	// Command is typed into the shell of the window. It may refer to the
+	// project with {{.Root}}, {{.Name}} and {{.Branch}}.
+	Command string `json:"command"`
+}
+
+// Root returns the directory repositories are cloned to.
+func (c Config) Root() string {
+	home := os.Getenv("HOME")
+	if c.CloneRoot == "" {
+		return filepath.Join(home, "src")
+	}
+	if rest, ok := strings.CutPrefix(c.CloneRoot, "~/"); ok {
+		return filepath.Join(home, rest)
+	}
+	return c.CloneRoot
+}
+
+// Timeout returns how long a hook may run, or zero for the default.
+func (c Config) Timeout() (time.Duration, error) {
+	if c.HookTimeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(c.HookTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("%w: hook_timeout: %w", ErrInvalidConfig, err)
+	}
+	return timeout, nil
+}
+
+// Level returns the lowest level to log.
+func (c Config) Level() (slog.Level, error) {
+	var level slog.Level
+	if c.LogLevel == "" {
+		return level, nil
+	}
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return level, fmt.Errorf("%w: log_level: %w", ErrInvalidConfig, err)
+	}
+	return level, nil
+}
+
+// Path returns the path of the global configuration file.
+func Path() string {
+	return filepath.Join(xdg.ConfigHome(), "config.json")
+}
+
+// Load reads the global configuration file. A missing file results in the
+// default configuration.
+func Load() (Config, error) {
+	var config Config
+	err := load(Path(), &config)
+	return config, err
+}
+
+func load(path string, config any) error {
+	data, err := read(path)
+	if data == nil || err != nil {
+		return err
+	}
+	return decode(path, data, config)
+}
+
+// read returns the contents of the file, or nil if it does not exist.
+func read(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func decode(path string, data []byte, config any) error {
+	if err := json.Unmarshal(data, config); err != nil {
+		return FileError{Path: path, Err: err}
+	}
+	return nil
+}
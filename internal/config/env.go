@@ -0,0 +1,79 @@
+package config
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Environment returns the environment variables of the project in dir as
+// KEY=value pairs sorted by key. The variables of the env files are read in
+// order, and the variables set in the configuration override them.
+func (p Project) Environment(dir string) ([]string, error) {
+	env := map[string]string{}
+	for _, path := range p.EnvPaths(dir) {
+		if err := loadEnv(path, env); err != nil {
+			return nil, err
+		}
+	}
+	maps.Copy(env, p.Env)
+
+	var pairs []string
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		pairs = append(pairs, key+"="+env[key])
+	}
+	return pairs, nil
+}
+
+// EnvPaths returns the paths of the env files of the project in dir.
+func (p Project) EnvPaths(dir string) []string {
+	var paths []string
+	for _, file := range p.EnvFiles {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		paths = append(paths, file)
+	}
+	return paths
+}
+
+// loadEnv reads the KEY=value lines of a .env file, or the export lines of a
+// .envrc file, into env. Other lines, such as the commands of an .envrc file,
+// and comments, are skipped. A missing file is not an error.
+func loadEnv(path string, env map[string]string) error {
+	data, err := read(path)
+	if data == nil || err != nil {
+		return err
+	}
+
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || !isEnvKey(key) {
+			continue
+		}
+		env[key] = unquote(strings.TrimSpace(value))
+	}
+	return nil
+}
+
+func isEnvKey(key string) bool {
+	if key == "" || key[0] >= '0' && key[0] <= '9' {
+		return false
+	}
+	for _, c := range key {
+		if c != '_' && (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"bytes"
+	"path/filepath"
+)
+
+// ProjectFile is the name of the project configuration file, read from the
+// project root.
+const ProjectFile = ".tmuxide.json"
+
+// Project is the configuration of a single project.
+type Project struct {
+	// Container runs the shells and the editor of the project in a container.
+	Container *Container `json:"container"`
+	// Hooks are run for the project after the global hooks.
+	Hooks Hooks `json:"hooks"`
+	// Env are the environment variables of the session of the project.
+	Env map[string]string `json:"env"`
+	// EnvFiles are .env or .envrc files, relative to the project root, to read
+	// environment variables from.
+	EnvFiles []string `json:"env_files"`
+}
+
+type Container struct {
+	// Engine is the container CLI, docker or podman. Defaults to docker.
+	Engine string `json:"engine"`
+	// Name is the name or ID of the container. Defaults to the container
+	// created for the project by the devcontainer CLI.
+	Name string `json:"name"`
+	// Workspace is the directory the project is mounted to in the container.
+	Workspace string `json:"workspace"`
+	// Devcontainer tells that the container was detected from a devcontainer
+	// configuration instead of being configured for the project.
+	Devcontainer bool `json:"-"`
+}
+
+// ParseProject parses the data of the project configuration file at path.
+// The data is passed in, so that the configuration is exactly the data that
+// was trusted. Projects without a container configuration but with a
+// devcontainer configuration run in the devcontainer.
+func ParseProject(path string, data []byte) (Project, error) {
+	var project Project
+	if err := decode(path, data, &project); err != nil {
+		return project, err
+	}
+
+	if project.Container != nil {
+		return project, nil
+	}
+
+	devcontainer, err := LoadDevcontainer(filepath.Dir(path))
+	project.Container = devcontainer.Container
+	return project, err
+}
+
+// LoadDevcontainer reads only the devcontainer configuration of the project
+// in dir, which runs the project in the devcontainer.
+func LoadDevcontainer(dir string) (Project, error) {
+	var project Project
+	for _, path := range []string{".devcontainer/devcontainer.json", ".devcontainer.json"} {
+		var devcontainer struct {
+			WorkspaceFolder string `json:"workspaceFolder"`
+		}
+		found, err := loadJSONC(filepath.Join(dir, path), &devcontainer)
+		if err != nil {
+			return project, err
+		}
+		if found {
+			project.Container = &Container{Workspace: devcontainer.WorkspaceFolder, Devcontainer: true}
+			break
+		}
+	}
+	return project, nil
+}
+
+// loadJSONC reads a JSON file that may contain comments and trailing commas,
+// like devcontainer.json does, and reports whether the file exists.
+func loadJSONC(path string, config any) (bool, error) {
+	data, err := read(path)
+	if data == nil || err != nil {
+		return false, err
+	}
+	return true, decode(path, stripJSONC(data), config)
+}
+
+// stripJSONC removes comments and trailing commas outside of strings.
+func stripJSONC(data []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && (data[i] != '*' || data[i+1] != '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = trimmed[:len(trimmed)-1]
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
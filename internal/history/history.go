@@ -0,0 +1,114 @@
+// Package history keeps the files and folders opened with tmuxide, so that
+// the frequently and recently opened ones can be suggested first.
+package history
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/eskelinenantti/tmuxide/internal/xdg"
+)
+
+// ErrCorrupt tells that the history file could not be parsed.
+var ErrCorrupt = errors.New("corrupt history")
+
+// MaxEntries is the number of targets kept. The targets with the lowest
+// scores are forgotten first.
+const MaxEntries = 500
+
+type Entry struct {
+	// Target is the absolute path of a local file or folder, or a remote
+	// target as it was given.
+	Target string    `json:"target"`
+	Count  int       `json:"count"`
+	Last   time.Time `json:"last"`
+}
+
+// Score ranks the entry by how often and how recently the target has been
+// opened.
+func (e Entry) Score(now time.Time) float64 {
+	age := now.Sub(e.Last)
+	switch {
+	case age < time.Hour:
+		return float64(e.Count) * 4
+	case age < 24*time.Hour:
+		return float64(e.Count) * 2
+	case age < 7*24*time.Hour:
+		return float64(e.Count) / 2
+	default:
+		return float64(e.Count) / 4
+	}
+}
+
+// Path returns the path of the file the history is kept in.
+func Path() string {
+	return filepath.Join(xdg.StateHome(), "history.json")
+}
+
+// Load returns the history, the highest scores first.
+func Load() ([]Entry, error) {
+	data, err := os.ReadFile(Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrCorrupt, Path(), err)
+	}
+	sortByScore(entries, time.Now())
+	return entries, nil
+}
+
+// Add records that the target was opened. A corrupt history is started over,
+// so that it doesn't stay broken for good.
+func Add(target string) error {
+	entries, err := Load()
+	if err != nil && !errors.Is(err, ErrCorrupt) {
+		return err
+	}
+
+	now := time.Now()
+	i := slices.IndexFunc(entries, func(entry Entry) bool {
+		return entry.Target == target
+	})
+	if i < 0 {
+		entries = append(entries, Entry{Target: target})
+		i = len(entries) - 1
+	}
+	entries[i].Count++
+	entries[i].Last = now
+
+	sortByScore(entries, now)
+	if len(entries) > MaxEntries {
+		entries = entries[:MaxEntries]
+	}
+	return save(entries)
+}
+
+func sortByScore(entries []Entry, now time.Time) {
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return cmp.Or(
+			cmp.Compare(b.Score(now), a.Score(now)),
+			b.Last.Compare(a.Last),
+		)
+	})
+}
+
+func save(entries []Entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return xdg.WriteFile(Path(), data)
+}
@@ -1,41 +1,132 @@
 package ide
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/quote"
 	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+	"github.com/eskelinenantti/tmuxide/internal/shell/version"
+	"github.com/eskelinenantti/tmuxide/internal/state"
 )
 
-func Start(command []string, project project.Project, tmux tmux.Cmd) error {
-	var err error
+var ErrNotAttached = errors.New("not inside a client of the tmux server")
+var ErrNoSession = errors.New("no session for the project")
+
+// Pane is where Peek opens a project in the current session.
+type Pane string
+
+const (
+	Window          Pane = "window"
+	HorizontalSplit Pane = "h"
+	VerticalSplit   Pane = "v"
+	Popup           Pane = "popup"
+)
+
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "fish": true, "dash": true, "ksh": true}
+
+// Prepare creates the project session and the window for the command without
+// switching or attaching to the session. It returns the name of the window the
+// command runs in, or an empty string if no command was given.
+func Prepare(command []string, project project.Project, tmux tmux.Cmd) (string, error) {
 	if len(command) == 0 {
-		err = startWithoutCommand(tmux, project)
-	} else {
-		err = startWithCommand(tmux, project, command)
+		return "", startWithoutCommand(tmux, project)
 	}
 
-	if err != nil {
+	window := windowName(command)
+	return window, startWithCommand(tmux, project, window, command)
+}
+
+// Run runs command in the given window of the project session, creating the
+// session if it does not exist yet. If the window already exists, it is left
+// running as is unless replace is set. When window is empty, the name is
+// derived from the command. Run returns the name of the window.
+func Run(command []string, window string, replace bool, project project.Project, tmux tmux.Cmd) (string, error) {
+	if window == "" {
+		window = windowName(command)
+	}
+
+	if tmux.HasSession(project.Name, window) {
+		if replace {
+			return window, tmux.NewWindow(project.Name, window, project.WorkingDir, window, project.Command(command))
+		}
+		return window, tmux.SelectWindow(project.Name, window)
+	}
+
+	return window, newWindow(tmux, project, window, window, command)
+}
+
+// Peek opens the command, or a shell if no command is given, in the project
+// working directory without leaving the session of the current client.
+func Peek(pane Pane, command []string, project project.Project, tmux tmux.Cmd) error {
+	if !isAttached(tmux) {
+		return ErrNotAttached
+	}
+
+	if pane == Popup && !tmux.Versions.Supports(version.TmuxPopup) {
+		// Popups are not available before tmux 3.2
+		pane = Window
+	}
+
+	switch pane {
+	case Window:
+		var window string
+		if len(command) > 0 {
+			window = windowName(command)
+		}
+		return tmux.OpenWindow(project.WorkingDir, window, project.Command(command))
+	case HorizontalSplit, VerticalSplit:
+		return tmux.Split(project.WorkingDir, pane == HorizontalSplit, project.Command(command))
+	default:
+		return tmux.Popup(project.WorkingDir, project.Command(command))
+	}
+}
+
+// Open switches the current client to the project session, or attaches to it
+// when not running inside a client of the tmux server. A client given
+// explicitly is always switched.
+func Open(proj project.Project, tmux tmux.Cmd) error {
+	if err := proj.Hook(project.Switch); err != nil {
 		return err
 	}
 
-	if isAttached() {
-		return tmux.Switch(project.Name)
+	if tmux.Client != "" || isAttached(tmux) {
+		return tmux.Switch(proj.Name)
 	}
 
-	return tmux.Attach(project.Name)
+	return tmux.Attach(proj.Name)
 }
 
-func startWithCommand(tmux tmux.Cmd, project project.Project, command []string) error {
-	windowName := command[0]
+// Kill kills the project session after running the kill hooks of the project.
+func Kill(proj project.Project, tmux tmux.Cmd) error {
+	if !tmux.HasSession(proj.Name, "") {
+		return ErrNoSession
+	}
 
-	if tmux.HasSession(project.Name, windowName) {
-		return tmux.NewWindow(project.Name, windowName, project.WorkingDir, windowName, command)
-	} else if tmux.HasSession(project.Name, "") {
-		return tmux.NewWindow(project.Name, "", project.WorkingDir, windowName, command)
-	} else {
-		return tmux.New(project.Name, project.WorkingDir, command)
+	if err := proj.Hook(project.Kill); err != nil {
+		return err
+	}
+	return tmux.KillSession(proj.Name)
+}
+
+func startWithCommand(tmux tmux.Cmd, project project.Project, window string, command []string) error {
+	if tmux.HasSession(project.Name, window) {
+		return tmux.NewWindow(project.Name, window, project.WorkingDir, window, project.Command(command))
 	}
+	return newWindow(tmux, project, window, "", command)
+}
+
+// newWindow creates a window for the command, or the whole session if it does
+// not exist yet. The first window of a new session is named only if
+// sessionWindow is set, otherwise tmux names it automatically.
+func newWindow(tmux tmux.Cmd, project project.Project, window string, sessionWindow string, command []string) error {
+	if tmux.HasSession(project.Name, "") {
+		return tmux.NewWindow(project.Name, "", project.WorkingDir, window, project.Command(command))
+	}
+	return newSession(tmux, project, sessionWindow, command)
 }
 
 func startWithoutCommand(tmux tmux.Cmd, project project.Project) error {
@@ -43,11 +134,210 @@ func startWithoutCommand(tmux tmux.Cmd, project project.Project) error {
 		// When no command was provided and session exists, don't create any new windows or sessions
 		return nil
 	} else {
-		return tmux.New(project.Name, project.WorkingDir, nil)
+		return newSession(tmux, project, "", nil)
+	}
+}
+
+// newSession creates the project session running the command in its first
+// window, followed by the other windows of the project. Sessions of projects
+// that are not on the local machine run any window created later in the
+// project environment too.
+func newSession(tmux tmux.Cmd, proj project.Project, window string, command []string) error {
+	err := tmux.New(proj.Name, proj.WorkingDir, window, proj.Env, proj.Command(command))
+	if err != nil {
+		return err
+	}
+
+	if proj.Exec != nil {
+		err = tmux.SetOption(proj.Name, "default-command", quote.Join(proj.Command(nil)))
+		if err != nil {
+			return err
+		}
+	}
+	if proj.Remote != "" {
+		// The path of the session doesn't tell the remote project apart
+		if err := tmux.SetRemote(proj.Name, proj.Remote); err != nil {
+			return err
+		}
+	}
+
+	for _, window := range proj.Windows {
+		index, err := tmux.AddWindow(proj.Name, proj.WorkingDir, window.Name, proj.Command(nil))
+		if err != nil {
+			return err
+		}
+
+		if window.Command == "" {
+			continue
+		}
+		// Typing the command into a shell keeps the window open after the
+		// command exits
+		if err := tmux.SendKeys(proj.Name, index, window.Command); err != nil {
+			return err
+		}
 	}
+	return proj.Hook(project.Create)
 }
 
-func isAttached() bool {
-	_, isAttached := os.LookupEnv("TMUX")
-	return isAttached
+// Snapshot returns the windows of the session with their panes, layout and
+// whether they are active.
+func Snapshot(session string, tmux tmux.Cmd) ([]state.Window, error) {
+	panes, err := tmux.ListPanes(session)
+	if err != nil {
+		return nil, err
+	}
+
+	var windows []state.Window
+	var index string
+	for _, pane := range panes {
+		if len(windows) == 0 || pane.WindowIndex != index {
+			index = pane.WindowIndex
+			windows = append(windows, state.Window{
+				Index:  pane.WindowIndex,
+				Name:   pane.WindowName,
+				Active: pane.WindowActive,
+				Layout: pane.WindowLayout,
+			})
+		}
+		window := &windows[len(windows)-1]
+		window.Panes = append(window.Panes, pane.Path)
+	}
+	return windows, nil
+}
+
+// Restore recreates the recorded session of the project with its windows and
+// panes. The first pane of each window runs the command recorded for it, and
+// the other panes run shells.
+func Restore(session state.Session, proj project.Project, tmux tmux.Cmd) error {
+	if len(session.Windows) == 0 {
+		return nil
+	}
+
+	first := session.Windows[0]
+	err := tmux.New(proj.Name, paneDir(proj, first.Panes, 0), first.Name, proj.Env, proj.Command(first.Command))
+	if err != nil {
+		return err
+	}
+
+	if proj.Exec != nil {
+		err = tmux.SetOption(proj.Name, "default-command", quote.Join(proj.Command(nil)))
+		if err != nil {
+			return err
+		}
+	}
+
+	// The first window stays the current window of the session, as the
+	// others are created without selecting them
+	var active string
+	for i, window := range session.Windows {
+		var index string
+		if i > 0 {
+			index, err = tmux.AddWindow(proj.Name, paneDir(proj, window.Panes, 0), window.Name, proj.Command(window.Command))
+			if err != nil {
+				return err
+			}
+			if window.Active {
+				active = index
+			}
+		}
+		if err := restoreWindow(tmux, proj, index, window); err != nil {
+			return err
+		}
+	}
+
+	if active != "" {
+		if err := tmux.SelectWindow(proj.Name, active); err != nil {
+			return err
+		}
+	}
+	return proj.Hook(project.Create)
+}
+
+// restoreWindow types the keys of the window with the index and recreates the
+// other panes of the window in its layout.
+func restoreWindow(tmux tmux.Cmd, proj project.Project, index string, window state.Window) error {
+	if window.Keys != "" {
+		if err := tmux.SendKeys(proj.Name, index, window.Keys); err != nil {
+			return err
+		}
+	}
+
+	for i := 1; i < len(window.Panes); i++ {
+		if err := tmux.AddPane(proj.Name, index, paneDir(proj, window.Panes, i), proj.Command(nil)); err != nil {
+			return err
+		}
+	}
+
+	if len(window.Panes) < 2 || window.Layout == "" {
+		return nil
+	}
+	return tmux.SelectLayout(proj.Name, index, window.Layout)
+}
+
+// paneDir returns the recorded directory of the pane if it still exists, and
+// the project working directory otherwise. The panes of projects that are not
+// on the local machine always start in the project directory.
+func paneDir(proj project.Project, panes []string, i int) string {
+	if proj.Exec != nil || i >= len(panes) {
+		return proj.WorkingDir
+	}
+	if info, err := os.Stat(panes[i]); err != nil || !info.IsDir() {
+		return proj.WorkingDir
+	}
+	return panes[i]
+}
+
+// windowName names a window after the program the command runs. Wrappers such
+// as env and shells started with -c are looked through, so that e.g.
+// `sh -c "make test"` gets named make instead of sh.
+func windowName(command []string) string {
+	args := command
+	for len(args) > 0 {
+		program := filepath.Base(args[0])
+		switch {
+		case program == "env":
+			args = envCommand(args[1:])
+		case shells[program]:
+			args = shellCommand(args[1:])
+		default:
+			return tmux.WindowName(program)
+		}
+	}
+	return tmux.WindowName(filepath.Base(command[0]))
+}
+
+func envCommand(args []string) []string {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
+			return args[i:]
+		}
+	}
+	return nil
+}
+
+func shellCommand(args []string) []string {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			// The shell runs a script file
+			return args[i:]
+		}
+		if !strings.HasPrefix(arg, "--") && strings.Contains(arg, "c") && i+1 < len(args) {
+			return strings.Fields(args[i+1])
+		}
+	}
+	return nil
+}
+
+// isAttached reports whether tmuxide runs inside a client of the tmux server
+// it talks to. $TMUX may belong to another server, for example when the socket
+// is selected explicitly or when tmux runs nested over SSH.
+func isAttached(tmux tmux.Cmd) bool {
+	env, ok := os.LookupEnv("TMUX")
+	if !ok {
+		return false
+	}
+
+	clientSocket, _, _ := strings.Cut(env, ",")
+	serverSocket, err := tmux.SocketPath()
+	return err == nil && clientSocket == serverSocket
 }
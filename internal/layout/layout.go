@@ -0,0 +1,92 @@
+package layout
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/eskelinenantti/tmuxide/internal/config"
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/quote"
+	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+)
+
+var ErrUnknownTemplate = errors.New("unknown template")
+
+type Git interface {
+	Branch(cwd string) (string, error)
+}
+
+// Data is what window commands can refer to in templates. The commands are
+// typed into a shell, so the values are quoted for the shell.
+type Data struct {
+	Root string
+	Name string
+	// root is the unquoted project root, for looking up the branch.
+	root string
+	git  Git
+}
+
+// Branch returns the current git branch of the project, or an empty string if
+// the project is not in a git repository. It is only looked up by templates
+// that refer to it.
+func (d Data) Branch() string {
+	branch, err := d.git.Branch(d.root)
+	if err != nil || branch == "" {
+		return ""
+	}
+	return quote.Quote(branch)
+}
+
+// Find returns the template with the given name.
+func Find(templates []config.Template, name string) (config.Template, error) {
+	for _, template := range templates {
+		if template.Name == name {
+			return template, nil
+		}
+	}
+	return config.Template{}, fmt.Errorf("%w %s", ErrUnknownTemplate, name)
+}
+
+// Detect returns the first template with a marker file in the directory.
+func Detect(templates []config.Template, dir string) (config.Template, bool) {
+	for _, template := range templates {
+		for _, marker := range template.Markers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return template, true
+			}
+		}
+	}
+	return config.Template{}, false
+}
+
+// Windows renders the windows of the template for the project.
+func Windows(tmpl config.Template, proj project.Project, git Git) ([]project.Window, error) {
+	var root string
+	if proj.WorkingDir != "" {
+		absoluteDir, err := filepath.Abs(proj.WorkingDir)
+		if err != nil {
+			return nil, err
+		}
+		root = absoluteDir
+	}
+	data := Data{Root: quote.Quote(root), Name: quote.Quote(proj.Name), root: root, git: git}
+
+	windows := make([]project.Window, len(tmpl.Windows))
+	for i, window := range tmpl.Windows {
+		parsed, err := template.New(window.Name).Parse(window.Command)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w: %w", tmpl.Name, config.ErrInvalidConfig, err)
+		}
+
+		var command strings.Builder
+		if err := parsed.Execute(&command, data); err != nil {
+			return nil, fmt.Errorf("template %s: %w: %w", tmpl.Name, config.ErrInvalidConfig, err)
+		}
+		windows[i] = project.Window{Name: tmux.WindowName(window.Name), Command: command.String()}
+	}
+	return windows, nil
+}
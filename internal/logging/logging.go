@@ -0,0 +1,50 @@
+// Package logging keeps a log of what tmuxide does, for finding out why a run
+// failed when its output was not seen, e.g. when run from a tmux key binding.
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/eskelinenantti/tmuxide/internal/xdg"
+)
+
+// MaxSize is the size at which the log is rotated. The previous log is kept
+// as ide.log.1.
+const MaxSize = 1 << 20
+
+// Path returns the path of the log file.
+func Path() string {
+	return filepath.Join(xdg.StateHome(), "ide.log")
+}
+
+// Open opens the log file for appending, rotating it first if it has grown
+// past MaxSize, and returns a logger writing records of the level or above to
+// it. The returned closer closes the file.
+func Open(level slog.Level) (*slog.Logger, io.Closer, error) {
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, err
+	}
+	if err := rotate(path); err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler := slog.NewTextHandler(file, &slog.HandlerOptions{Level: level})
+	return slog.New(handler).With("pid", os.Getpid()), file, nil
+}
+
+func rotate(path string) error {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < MaxSize {
+		// A missing log is created when opened
+		return nil
+	}
+	return os.Rename(path, path+".1")
+}
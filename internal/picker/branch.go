@@ -0,0 +1,28 @@
+package picker
+
+import (
+	"errors"
+
+	"github.com/eskelinenantti/tmuxide/internal/shell/fzf"
+	"github.com/eskelinenantti/tmuxide/internal/shell/git"
+)
+
+var ErrNoBranches = errors.New("no branches in the repository")
+
+// PickBranch lets the user pick one of the branches. It returns false if the
+// user cancelled picking.
+func PickBranch(fzf fzf.Cmd, branches []git.Branch) (git.Branch, bool, error) {
+	if len(branches) == 0 {
+		return git.Branch{}, false, ErrNoBranches
+	}
+
+	names := make([]string, len(branches))
+	for i, branch := range branches {
+		names[i] = branch.Name
+	}
+	i, err := pick(fzf, names)
+	if i < 0 || err != nil {
+		return git.Branch{}, false, err
+	}
+	return branches[i], true, nil
+}
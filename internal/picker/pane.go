@@ -0,0 +1,124 @@
+package picker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/eskelinenantti/tmuxide/internal/shell/fzf"
+)
+
+var ErrNoLocations = errors.New("no files or folders in the pane")
+
+// Location is a file or folder found in the output of a pane, with the line
+// if it was given, e.g. internal/project/project.go:42:7. The column is only
+// kept in the text, as editors are opened at a line.
+type Location struct {
+	// Text is the location as it was in the pane.
+	Text string
+	// Path is the absolute path of the file or folder.
+	Path string
+	Line int
+}
+
+// location matches paths with an optional line and column. Paths must have a
+// slash or a dot so that ordinary words are not taken for files.
+var location = regexp.MustCompile(`(~?[\w.@%+,/-]*[\w/][\w.@%+,/-]*)(?::(\d+))?(?::(\d+))?`)
+
+// Locations returns the files and folders that exist in the output of a pane,
+// resolved against the working directory of the pane. The locations printed
+// last come first, as they are likely the most relevant.
+func Locations(output string, dir string) []Location {
+	var locations []Location
+	seen := map[string]bool{}
+	lines := strings.Split(output, "\n")
+	for _, line := range slices.Backward(lines) {
+		for _, match := range location.FindAllStringSubmatch(line, -1) {
+			// Paths at the end of a sentence are followed by a period
+			path := strings.TrimRight(match[1], ".,")
+			text := path
+			if match[2] != "" {
+				text += ":" + match[2]
+			}
+			if match[3] != "" {
+				text += ":" + match[3]
+			}
+			if !strings.ContainsAny(path, "/.") || seen[text] {
+				continue
+			}
+
+			// The pane is already in its own directory, e.g. in go test ./...
+			path = resolvePath(path, dir)
+			if _, err := os.Stat(path); err != nil || path == filepath.Clean(dir) {
+				continue
+			}
+
+			seen[text] = true
+			line, _ := strconv.Atoi(match[2])
+			locations = append(locations, Location{Text: text, Path: path, Line: line})
+		}
+	}
+	return locations
+}
+
+func resolvePath(path string, dir string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(os.Getenv("HOME"), rest)
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// PickLocation lets the user pick one of the locations. It returns false if
+// the user cancelled picking.
+func PickLocation(fzf fzf.Cmd, locations []Location) (Location, bool, error) {
+	if len(locations) == 0 {
+		return Location{}, false, ErrNoLocations
+	}
+
+	texts := make([]string, len(locations))
+	for i, location := range locations {
+		texts[i] = location.Text
+	}
+	i, err := pick(fzf, texts)
+	if i < 0 || err != nil {
+		return Location{}, false, err
+	}
+	return locations[i], true, nil
+}
+
+// pick lets the user pick one of the entries, and returns its index, or -1 if
+// the user cancelled picking.
+func pick(fzf fzf.Cmd, entries []string) (int, error) {
+	var buffer bytes.Buffer
+	fzfStdin, err := fzf.Fzf(&buffer, false)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, entry := range entries {
+		if _, err := fmt.Fprintln(fzfStdin, entry); err != nil {
+			// The picker was closed before all entries were written
+			break
+		}
+	}
+
+	err = fzfStdin.Close()
+	if IsUserCancelledErr(err) {
+		return -1, nil
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	selection := strings.TrimSpace(buffer.String())
+	return slices.Index(entries, selection), nil
+}
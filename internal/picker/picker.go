@@ -2,6 +2,7 @@ package picker
 
 import (
 	"bytes"
+	"cmp"
 	"errors"
 	"os"
 	"os/exec"
@@ -13,9 +14,12 @@ import (
 	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
 )
 
-func Prompt(tmux tmux.Cmd, fd fd.Cmd, fzf fzf.Cmd) (string, error) {
+// Prompt lets the user pick a file or folder, and returns its path. With
+// allowNew, a query that matches nothing is returned as the path of a new
+// file or folder.
+func Prompt(tmux tmux.Cmd, fd fd.Cmd, fzf fzf.Cmd, remotes Remotes, allowNew bool) (string, error) {
 	var buffer bytes.Buffer
-	fzfStdin, err := fzf.Fzf(&buffer)
+	fzfStdin, err := fzf.Fzf(&buffer, allowNew)
 	if err != nil {
 		return "", err
 	}
@@ -25,8 +29,11 @@ func Prompt(tmux tmux.Cmd, fd fd.Cmd, fzf fzf.Cmd) (string, error) {
 		return "", err
 	}
 
+	// Remote entries come last so that slow hosts don't delay local entries
+	remotes.write(fzfStdin)
+
 	err = fzfStdin.Close()
-	if err != nil {
+	if err != nil && !(allowNew && isNoMatchErr(err)) {
 		if IsUserCancelledErr(err) {
 			return "", nil
 		}
@@ -34,7 +41,28 @@ func Prompt(tmux tmux.Cmd, fd fd.Cmd, fzf fzf.Cmd) (string, error) {
 	}
 
 	selection := strings.TrimSpace(buffer.String())
-	return filepath.Join(os.Getenv("HOME"), selection), nil
+	if allowNew {
+		query, match, _ := strings.Cut(selection, "\n")
+		selection = cmp.Or(strings.TrimSpace(match), strings.TrimSpace(query))
+		if selection == "" {
+			return "", nil
+		}
+	}
+	if remotes.isRemote(selection) {
+		return selection, nil
+	}
+	path := filepath.Join(os.Getenv("HOME"), selection)
+	if strings.HasSuffix(selection, "/") {
+		// Keep telling new folders apart from new files
+		path += "/"
+	}
+	return path, nil
+}
+
+// isNoMatchErr reports whether fzf exited because the query matched nothing.
+func isNoMatchErr(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == 1
 }
 
 func IsUserCancelledErr(err error) bool {
@@ -0,0 +1,157 @@
+package picker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eskelinenantti/tmuxide/internal/shell/ssh"
+	"github.com/eskelinenantti/tmuxide/internal/xdg"
+)
+
+const cacheTTL = 10 * time.Minute
+const listTimeout = 5 * time.Second
+
+// Remotes lists the files and folders of remote hosts in the picker, prefixed
+// with the host, e.g. host:path/to/project.
+type Remotes struct {
+	Ssh   ssh.Cmd
+	Hosts []string
+}
+
+// write writes the entries of the hosts to the output as they are listed. The
+// hosts are listed at the same time, so that slow hosts don't delay the
+// others, and the listings still running are cancelled once the picker is
+// closed.
+func (r Remotes) write(output io.Writer) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lines := make(chan string)
+	var wg sync.WaitGroup
+	for _, host := range r.Hosts {
+		wg.Go(func() { r.list(ctx, host, lines) })
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	for line := range lines {
+		if ctx.Err() != nil {
+			// Wait for the cancelled listings to stop
+			continue
+		}
+		if _, err := io.WriteString(output, line); err != nil {
+			// The picker was closed before all entries were written
+			cancel()
+		}
+	}
+}
+
+// list sends the entries of the host to lines as they are listed, prefixed with
+// the host. Cached entries are sent instead if they are recent enough, and
+// entries listed earlier are sent if the host can't be reached in time.
+func (r Remotes) list(ctx context.Context, host string, lines chan<- string) {
+	cache := filepath.Join(xdg.CacheHome(), "hosts", url.PathEscape(host))
+	cached, cacheErr := os.ReadFile(cache)
+	if info, err := os.Stat(cache); err == nil && time.Since(info.ModTime()) < cacheTTL {
+		send(ctx, lines, host, string(cached))
+		return
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, listTimeout)
+	defer cancel()
+
+	out := &lineWriter{ctx: ctx, host: host, lines: lines}
+	err := r.Ssh.List(listCtx, host, out)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		if cacheErr == nil {
+			send(ctx, lines, host, out.unsent(string(cached)))
+		}
+		return
+	}
+	out.flush()
+
+	if err := os.MkdirAll(filepath.Dir(cache), 0755); err == nil {
+		// Failing to cache the entries only makes the next listing slower
+		_ = os.WriteFile(cache, out.buffer.Bytes(), 0644)
+	}
+}
+
+// send sends each line of the entries, unless the context is cancelled first.
+func send(ctx context.Context, lines chan<- string, host string, entries string) bool {
+	for entry := range strings.Lines(entries) {
+		select {
+		case lines <- host + ":" + strings.TrimSuffix(entry, "\n") + "\n":
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// lineWriter sends the entries written to it as soon as their lines are
+// complete, and keeps all of them to be cached.
+type lineWriter struct {
+	ctx    context.Context
+	host   string
+	lines  chan<- string
+	buffer bytes.Buffer
+	// sent is the length of the entries already sent.
+	sent int
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buffer.Write(p)
+	end := bytes.LastIndexByte(w.buffer.Bytes(), '\n') + 1
+	if end <= w.sent {
+		return len(p), nil
+	}
+	entries := string(w.buffer.Bytes()[w.sent:end])
+	w.sent = end
+	if !send(w.ctx, w.lines, w.host, entries) {
+		return 0, w.ctx.Err()
+	}
+	return len(p), nil
+}
+
+// flush sends the last entry if it does not end with a newline.
+func (w *lineWriter) flush() {
+	send(w.ctx, w.lines, w.host, string(w.buffer.Bytes()[w.sent:]))
+	w.sent = w.buffer.Len()
+}
+
+// unsent returns the cached entries that were not sent before the listing
+// failed.
+func (w *lineWriter) unsent(cached string) string {
+	sent := make(map[string]bool)
+	for entry := range strings.Lines(string(w.buffer.Bytes()[:w.sent])) {
+		sent[entry] = true
+	}
+	var entries strings.Builder
+	for entry := range strings.Lines(cached) {
+		if !sent[strings.TrimSuffix(entry, "\n")+"\n"] {
+			entries.WriteString(entry)
+		}
+	}
+	return entries.String()
+}
+
+func (r Remotes) isRemote(selection string) bool {
+	for _, host := range r.Hosts {
+		if strings.HasPrefix(selection, host+":") {
+			return true
+		}
+	}
+	return false
+}
@@ -11,15 +11,99 @@ import (
 
 var ErrInvalidPath = errors.New("invalid path")
 
+// PathError is the error of a target that could not be resolved to a file or
+// folder.
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (e PathError) Error() string {
+	return fmt.Sprintf("%v %s: %v", ErrInvalidPath, e.Path, e.Err)
+}
+
+func (e PathError) Unwrap() []error {
+	return []error{ErrInvalidPath, e.Err}
+}
+
 type Project struct {
 	Name       string
 	WorkingDir string
+	// Exec runs the commands of projects that are not on the local machine.
+	// The working directory of such projects is empty.
+	Exec Exec
+	// Remote is the target that opens the project on a remote host, such as
+	// host:/path/to/repo, which stands in for the working directory.
+	Remote string
+	// Windows are created in addition to the first window when the session of
+	// the project is created.
+	Windows []Window
+	// Hooks run the scripts configured for the events of the project session.
+	Hooks Hooks
+	// Env are the KEY=value environment variables of the project session.
+	Env []string
+}
+
+type Window struct {
+	Name string
+	// Command is typed into the shell of the window, if not empty.
+	Command string
 }
 
 type Git interface {
 	RevParse(cwd string) (string, error)
 }
 
+// Exec wraps commands so that they run in the environment of the project, for
+// example on a remote host or in a container.
+type Exec interface {
+	// Command returns the command that runs the given command in the project
+	// directory, or a shell if the given command is empty.
+	Command(command []string) []string
+	// Path maps a path of the project to the path the commands see.
+	Path(path string) string
+}
+
+// Event is a point in the life of a project session that hooks run at.
+type Event string
+
+const (
+	// Create is after the session has been created.
+	Create Event = "create"
+	// Switch is before a client switches or attaches to the session.
+	Switch Event = "switch"
+	// Kill is before the session is killed.
+	Kill Event = "kill"
+)
+
+// Hooks run the scripts configured for an event.
+type Hooks interface {
+	Run(event Event) error
+}
+
+// Remote resolves files on remote hosts.
+type Remote interface {
+	Resolve(host string, target string) (string, bool, string, error)
+	Exec(host string, dir string) Exec
+}
+
+// Command returns the command that runs the given command in the project
+// environment. For local projects, the command is returned as is.
+func (p Project) Command(command []string) []string {
+	if p.Exec == nil {
+		return command
+	}
+	return p.Exec.Command(command)
+}
+
+// Hook runs the hooks of the event, if the project has any.
+func (p Project) Hook(event Event) error {
+	if p.Hooks == nil {
+		return nil
+	}
+	return p.Hooks.Run(event)
+}
+
 func ForFile(file string, git Git) (Project, error) {
 	workingDir, err := repository(file, git)
 	if err != nil {
@@ -40,6 +124,26 @@ func ForFile(file string, git Git) (Project, error) {
 	}, nil
 }
 
+// ForNewFile returns the project for a file that does not exist yet. The
+// project is the repository of the nearest existing ancestor directory, or
+// the directory the file is going to be created in if there is no repository.
+func ForNewFile(file string, git Git) (Project, error) {
+	workingDir, err := git.RevParse(existingAncestor(file))
+	if err != nil {
+		workingDir = filepath.Dir(file)
+	}
+
+	absolutePath, err := filepath.Abs(workingDir)
+	if err != nil {
+		return Project{}, err
+	}
+
+	return Project{
+		Name:       Name(absolutePath),
+		WorkingDir: workingDir,
+	}, nil
+}
+
 func ForDir(directory string) (Project, error) {
 	absoluteDir, err := filepath.Abs(directory)
 	if err != nil {
@@ -52,16 +156,114 @@ func ForDir(directory string) (Project, error) {
 	}, nil
 }
 
+// Path maps a path of the project to the path its commands see. For local
+// projects, the path is returned as is.
+func (p Project) Path(path string) string {
+	if p.Exec == nil {
+		return path
+	}
+	return p.Exec.Path(path)
+}
+
+// ForRemote returns the project for a file or folder on a remote host. As with
+// local targets, the project of a file is the repository it is in, or the
+// directory of the file. The returned path is the absolute path of the target
+// on the host.
+func ForRemote(host string, target string, remote Remote) (Project, string, bool, error) {
+	path, isDir, repository, err := remote.Resolve(host, target)
+	if err != nil {
+		return Project{}, "", false, PathError{Path: host + ":" + target, Err: err}
+	}
+
+	dir := repository
+	switch {
+	case isDir:
+		dir = path
+	case dir == "":
+		dir = filepath.Dir(path)
+	}
+
+	return Project{
+		Name:   RemoteName(host, dir),
+		Exec:   remote.Exec(host, dir),
+		Remote: RemoteTarget(host, dir),
+	}, path, isDir, nil
+}
+
+// RemoteTarget returns the target of the absolute path on the host, in the
+// form the host was given in.
+func RemoteTarget(host string, path string) string {
+	if strings.HasPrefix(host, "ssh://") {
+		return host + path
+	}
+	return host + ":" + path
+}
+
+// ParseRemote splits a remote target given either as ssh://[user@]host[:port]/path
+// or as host:path into the ssh destination and the path on the host. Paths of
+// the latter form are relative to the home directory of the remote user.
+// Locations of local files, such as main.go:42 or notes.txt:draft, are not
+// remote targets.
+func ParseRemote(target string) (string, string, bool) {
+	if rest, ok := strings.CutPrefix(target, "ssh://"); ok {
+		destination, path, _ := strings.Cut(rest, "/")
+		if destination == "" {
+			return "", "", false
+		}
+		return "ssh://" + destination, "/" + path, true
+	}
+
+	host, path, ok := strings.Cut(target, ":")
+	if !ok || host == "" || strings.Contains(host, "/") {
+		return "", "", false
+	}
+	if path != "" && strings.Trim(path, "0123456789:") == "" {
+		// A line and column, as printed by compilers
+		return "", "", false
+	}
+	if info, err := os.Stat(host); err == nil && !info.IsDir() {
+		return "", "", false
+	}
+	if path == "" {
+		path = "."
+	}
+	return host, path, true
+}
+
+// IsSession reports whether the session is the one tmuxide names for the
+// directory, which tells tmuxide sessions apart from other tmux sessions.
+func IsSession(session string, dir string) bool {
+	absoluteDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	return Name(absoluteDir) == session
+}
+
 func Name(path string) string {
 	basename := filepath.Base(path)
 	sessionPrefix := strings.ReplaceAll(basename, ".", "_")
 	return strings.Join([]string{sessionPrefix, hash(path)}, "-")
 }
 
+// RemoteName names the session after the remote directory and the host, e.g.
+// repo@example_com-1a2b.
+func RemoteName(host string, dir string) string {
+	hostname := strings.TrimPrefix(host, "ssh://")
+	if _, after, ok := strings.Cut(hostname, "@"); ok {
+		hostname = after
+	}
+	hostname, _, _ = strings.Cut(hostname, ":")
+
+	basename := filepath.Base(dir)
+	sessionPrefix := strings.ReplaceAll(basename+"@"+hostname, ".", "_")
+	return strings.Join([]string{sessionPrefix, hash(host + ":" + dir)}, "-")
+}
+
 func dir(target string) (string, error) {
 	fileInfo, err := os.Stat(target)
 	if err != nil {
-		return "", ErrInvalidPath
+		return "", PathError{Path: target, Err: err}
 	}
 
 	if !fileInfo.IsDir() {
@@ -71,6 +273,16 @@ func dir(target string) (string, error) {
 	return target, nil
 }
 
+func existingAncestor(target string) string {
+	dir := filepath.Dir(target)
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			return dir
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
 func repository(target string, git Git) (string, error) {
 	fileInfo, err := os.Stat(target)
 	if err != nil {
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// URL is the location of a git repository that can be cloned.
+type URL struct {
+	Raw  string
+	Host string
+	// Path is the path of the repository on the host without the .git suffix,
+	// e.g. org/repo.
+	Path string
+}
+
+// Parse recognizes git repository URLs, either with a http, https, git or
+// ssh scheme, or in the scp-like form of git@host:org/repo.git. URLs over ssh
+// must have the .git suffix or the git user, so that they are not mistaken for
+// remote targets.
+func Parse(target string) (URL, bool) {
+	if u, err := url.Parse(target); err == nil && u.Scheme != "" && u.Host != "" {
+		switch u.Scheme {
+		case "http", "https", "git":
+		case "ssh":
+			if !isGitPath(u.Path) && u.User.Username() != "git" {
+				return URL{}, false
+			}
+		default:
+			return URL{}, false
+		}
+		return newURL(target, u.Hostname(), u.Path)
+	}
+
+	destination, repositoryPath, ok := strings.Cut(target, ":")
+	if !ok || strings.Contains(destination, "/") {
+		return URL{}, false
+	}
+	user, host, hasUser := strings.Cut(destination, "@")
+	if !hasUser {
+		host = destination
+	}
+	if user != "git" && !isGitPath(repositoryPath) {
+		return URL{}, false
+	}
+	return newURL(target, host, repositoryPath)
+}
+
+// Dir returns the directory the repository is cloned to under the root, e.g.
+// root/github.com/org/repo.
+func (u URL) Dir(root string) string {
+	return filepath.Join(root, u.Host, filepath.FromSlash(u.Path))
+}
+
+func newURL(raw string, host string, repositoryPath string) (URL, bool) {
+	repositoryPath = strings.TrimSuffix(path.Clean("/"+repositoryPath), ".git")
+	repositoryPath = strings.TrimPrefix(repositoryPath, "/")
+	if host == "" || repositoryPath == "" || strings.ContainsAny(host, `/\`) || host == ".." {
+		return URL{}, false
+	}
+	return URL{Raw: raw, Host: host, Path: repositoryPath}, true
+}
+
+func isGitPath(repositoryPath string) bool {
+	return strings.HasSuffix(repositoryPath, ".git")
+}
@@ -0,0 +1,96 @@
+package container
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+)
+
+var ErrContainerNotFound = errors.New("container not found")
+
+type Cmd struct {
+	runner.Runner
+	// KeepStopped leaves stopped containers stopped, for commands that only
+	// need to know the container, such as ide kill.
+	KeepStopped bool
+}
+
+// Container runs the commands of a project in a container, with the project
+// directory mounted to the workspace folder of the container.
+type Container struct {
+	Engine    string
+	Name      string
+	Dir       string
+	Workspace string
+	// Env are the names of the variables passed from the session environment
+	// to the container.
+	Env []string
+}
+
+// FindDevcontainer returns the ID of the container the devcontainer CLI created
+// for the project directory.
+func (c Cmd) FindDevcontainer(engine string, dir string) (string, error) {
+	cmd := exec.Command(engine, "ps", "--all", "--quiet", "--filter", "label=devcontainer.local_folder="+dir)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := c.Run(cmd); err != nil {
+		return "", err
+	}
+
+	id, _, _ := strings.Cut(strings.TrimSpace(out.String()), "\n")
+	if id == "" {
+		return "", fmt.Errorf("%w for %s, create it with devcontainer up", ErrContainerNotFound, dir)
+	}
+	return id, nil
+}
+
+// Start starts the container unless it is running already, or KeepStopped is
+// set.
+func (c Cmd) Start(engine string, name string) error {
+	if c.KeepStopped {
+		return nil
+	}
+
+	cmd := exec.Command(engine, "inspect", "--format", "{{.State.Running}}", name)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := c.Run(cmd); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrContainerNotFound, name, err)
+	}
+
+	if strings.TrimSpace(out.String()) == "true" {
+		return nil
+	}
+	return c.Run(exec.Command(engine, "start", name))
+}
+
+// Command returns the command that runs the given command in the container, or
+// the shell of the container if no command is given.
+func (c Container) Command(command []string) []string {
+	if len(command) == 0 {
+		command = []string{"sh", "-c", "exec ${SHELL:-sh} -l"}
+	}
+	args := []string{c.Engine, "exec", "-it", "-w", c.Workspace}
+	for _, name := range c.Env {
+		args = append(args, "-e", name)
+	}
+	return append(append(args, c.Name), command...)
+}
+
+// Path maps a path in the project directory to the path in the container.
+func (c Container) Path(path string) string {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	relativePath, err := filepath.Rel(c.Dir, absolutePath)
+	if err != nil || strings.HasPrefix(relativePath, "..") {
+		return path
+	}
+	return filepath.Join(c.Workspace, relativePath)
+}
@@ -2,6 +2,7 @@ package fd
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
@@ -9,6 +10,8 @@ import (
 	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
 )
 
+var ErrSearchFailed = errors.New("fd could not list the files")
+
 type Cmd struct {
 	runner.Runner
 }
@@ -34,7 +37,7 @@ func (f Cmd) Fd(output io.Writer) error {
 			// This error occurs if fzf closes the pipe before the command is completed
 			return nil
 		}
-		return err
+		return fmt.Errorf("%w: %w", ErrSearchFailed, err)
 	}
 	return nil
 }
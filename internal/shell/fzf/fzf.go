@@ -1,29 +1,69 @@
 package fzf
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
 
+	"github.com/eskelinenantti/tmuxide/internal/shell/path"
 	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/version"
 )
 
+var ErrFailed = errors.New("fzf failed")
+
 type Cmd struct {
 	runner.Runner
+	// Versions tells whether the installed fzf can open itself in tmux.
+	Versions version.Cmd
+	// Path is used to find fzf-tmux for versions of fzf without --tmux.
+	Path path.ShellPath
 }
 
-func (f Cmd) Fzf(output io.Writer) (runner.WriteCloser, error) {
-	fzfCmd := exec.Command(
-		"fzf",
-		"--reverse",
-		"--height",
-		"70%",
-		"--tmux",
-		"70%",
-	)
+// Fzf starts fzf, writing the selection to the output. With printQuery, the
+// query is written on the line before the selection.
+func (f Cmd) Fzf(output io.Writer, printQuery bool) (runner.WriteCloser, error) {
+	args := f.command()
+	fzfCmd := exec.Command(args[0], args[1:]...)
+	if printQuery {
+		fzfCmd.Args = append(fzfCmd.Args, "--print-query")
+	}
 	fzfCmd.Stdout = output
 	fzfCmd.Stderr = os.Stderr
 	waiter, err := f.Start(fzfCmd)
-	return waiter, err
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailed, err)
+	}
+	return closer{waiter}, nil
+}
+
+// closer tells apart fzf failing from the user cancelling it or the query
+// matching nothing, which fzf reports with exit codes 130 and 1.
+type closer struct {
+	runner.WriteCloser
+}
+
+func (c closer) Close() error {
+	err := c.WriteCloser.Close()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 2 {
+		return fmt.Errorf("%w: %w", ErrFailed, err)
+	}
+	return err
+}
 
+// command returns the command that opens fzf in a tmux popup when possible.
+// fzf versions before 0.53 fall back to fzf-tmux, which opens fzf in a split
+// pane, or to running fzf inline if fzf-tmux is not installed either.
+func (f Cmd) command() []string {
+	switch {
+	case f.Versions.Supports(version.FzfTmux):
+		return []string{"fzf", "--reverse", "--height", "70%", "--tmux", "70%"}
+	case f.Path != nil && f.Path.Contains("fzf-tmux"):
+		return []string{"fzf-tmux", "-d", "70%", "--", "--reverse"}
+	default:
+		return []string{"fzf", "--reverse", "--height", "70%"}
+	}
 }
@@ -2,16 +2,33 @@ package git
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
 )
 
+var ErrNotRepository = errors.New("not a git repository")
+var ErrCloneFailed = errors.New("git clone failed")
+
 type Cmd struct {
 	runner.Runner
 }
 
+// Run runs the git command, telling apart the failures with a known cause by
+// what git printed to stderr.
+func (g Cmd) Run(cmd *exec.Cmd) error {
+	err := g.Runner.Run(cmd)
+	if err != nil && strings.Contains(runner.Stderr(err), "not a git repository") {
+		return fmt.Errorf("%w: %w", ErrNotRepository, err)
+	}
+	return err
+}
+
 func (g Cmd) RevParse(cwd string) (string, error) {
 	cmd := exec.Command("git", "-C", cwd, "rev-parse", "--show-toplevel")
 	var out bytes.Buffer
@@ -19,3 +36,138 @@ func (g Cmd) RevParse(cwd string) (string, error) {
 	err := g.Run(cmd)
 	return strings.TrimSpace(out.String()), err
 }
+
+func (g Cmd) Clone(url string, dir string) error {
+	cmd := exec.Command("git", "clone", "--", url, dir)
+	// Show the progress of the clone, and the reason if it fails
+	cmd.Stderr = os.Stderr
+	if err := g.Run(cmd); err != nil {
+		return fmt.Errorf("%w: %w", ErrCloneFailed, err)
+	}
+	return nil
+}
+
+func (g Cmd) Branch(cwd string) (string, error) {
+	cmd := exec.Command("git", "-C", cwd, "rev-parse", "--abbrev-ref", "HEAD")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := g.Run(cmd)
+	return strings.TrimSpace(out.String()), err
+}
+
+// Status is the state of the working tree of a repository.
+type Status struct {
+	Branch string
+	// Ahead and Behind are the numbers of commits the branch is ahead and
+	// behind of its upstream branch.
+	Ahead  int
+	Behind int
+	// Changed is the number of changed and untracked files.
+	Changed int
+}
+
+func (g Cmd) Status(cwd string) (Status, error) {
+	// Refreshing the status line must not take the index lock from git
+	// commands run in the repository at the same time
+	cmd := exec.Command("git", "--no-optional-locks", "-C", cwd, "status", "--porcelain=v2", "--branch")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := g.Run(cmd); err != nil {
+		return Status{}, err
+	}
+
+	var status Status
+	for line := range strings.Lines(out.String()) {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "# branch.head "):
+			status.Branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fmt.Sscanf(strings.TrimPrefix(line, "# branch.ab "), "+%d -%d", &status.Ahead, &status.Behind)
+		case !strings.HasPrefix(line, "#"):
+			status.Changed++
+		}
+	}
+	return status, nil
+}
+
+// Branch is a branch of a repository.
+type Branch struct {
+	// Name is the name of the branch, e.g. main, or origin/feature for
+	// remote branches.
+	Name string
+	// Remote is the remote of remote branches, e.g. origin.
+	Remote string
+}
+
+// Local returns the name of the local branch, which for remote branches is
+// the name without the remote.
+func (b Branch) Local() string {
+	if b.Remote == "" {
+		return b.Name
+	}
+	return strings.TrimPrefix(b.Name, b.Remote+"/")
+}
+
+// Branches returns the local branches of the repository, followed by the
+// remote branches that have no local branch of the same name.
+func (g Cmd) Branches(cwd string) ([]Branch, error) {
+	cmd := exec.Command("git", "-C", cwd, "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := g.Run(cmd); err != nil {
+		return nil, err
+	}
+
+	var local, remote []Branch
+	for ref := range strings.Lines(out.String()) {
+		ref = strings.TrimSuffix(ref, "\n")
+		if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			local = append(local, Branch{Name: name})
+		} else if name, ok := strings.CutPrefix(ref, "refs/remotes/"); ok && !strings.HasSuffix(name, "/HEAD") {
+			remoteName, _, _ := strings.Cut(name, "/")
+			remote = append(remote, Branch{Name: name, Remote: remoteName})
+		}
+	}
+
+	branches := local
+	for _, branch := range remote {
+		if !slices.ContainsFunc(local, func(b Branch) bool { return b.Name == branch.Local() }) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+// Worktrees returns the directories of the worktrees of the repository by the
+// branch checked out in them.
+func (g Cmd) Worktrees(cwd string) (map[string]string, error) {
+	cmd := exec.Command("git", "-C", cwd, "worktree", "list", "--porcelain")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := g.Run(cmd); err != nil {
+		return nil, err
+	}
+
+	worktrees := map[string]string{}
+	var dir string
+	for line := range strings.Lines(out.String()) {
+		line = strings.TrimSuffix(line, "\n")
+		if worktree, ok := strings.CutPrefix(line, "worktree "); ok {
+			dir = worktree
+		} else if branch, ok := strings.CutPrefix(line, "branch refs/heads/"); ok {
+			worktrees[branch] = dir
+		}
+	}
+	return worktrees, nil
+}
+
+// AddWorktree checks out the branch in a new worktree in the directory. With
+// a start point, such as origin/feature, the branch is created from it.
+func (g Cmd) AddWorktree(cwd string, dir string, branch string, startPoint string) error {
+	cmd := exec.Command("git", "-C", cwd, "worktree", "add", dir, branch)
+	if startPoint != "" {
+		cmd = exec.Command("git", "-C", cwd, "worktree", "add", "--track", "-b", branch, dir, startPoint)
+	}
+	return g.Run(cmd)
+}
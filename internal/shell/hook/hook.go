@@ -0,0 +1,98 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+)
+
+// DefaultTimeout is how long a hook may run unless configured otherwise.
+const DefaultTimeout = 30 * time.Second
+
+var ErrTimeout = errors.New("timed out")
+
+// Error is returned when a hook fails or does not finish in time.
+type Error struct {
+	Event   project.Event
+	Command string
+	Err     error
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s hook '%s' failed: %v", e.Event, e.Command, e.Err)
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+type Cmd struct {
+	runner.Runner
+	Timeout time.Duration
+}
+
+// Run runs the script with sh in dir, with env added to the environment.
+// The output of the script goes to stderr so that it doesn't mix with the
+// output of tmuxide.
+func (c Cmd) Run(script string, dir string, env []string) error {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", script)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	// Kill the processes the script started too when it times out, so that
+	// they don't linger.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+
+	err := c.Runner.Run(cmd)
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w after %v", ErrTimeout, timeout)
+	}
+	return err
+}
+
+// Hooks runs the scripts of a project by event. The scripts see the
+// environment of the project, and the project name, root and the target
+// tmuxide was started with as $TMUXIDE_PROJECT, $TMUXIDE_ROOT and
+// $TMUXIDE_TARGET.
+type Hooks struct {
+	Cmd     Cmd
+	Scripts map[project.Event][]string
+	Project project.Project
+	Target  string
+}
+
+// Run runs the scripts of the event in order, stopping at the first one that
+// fails.
+func (h Hooks) Run(event project.Event) error {
+	env := append(slices.Clone(h.Project.Env),
+		"TMUXIDE_PROJECT="+h.Project.Name,
+		"TMUXIDE_ROOT="+h.Project.WorkingDir,
+		"TMUXIDE_TARGET="+h.Target,
+		"TMUXIDE_EVENT="+string(event),
+	)
+	for _, script := range h.Scripts[event] {
+		if err := h.Cmd.Run(script, h.Project.WorkingDir, env); err != nil {
+			return Error{Event: event, Command: script, Err: err}
+		}
+	}
+	return nil
+}
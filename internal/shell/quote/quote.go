@@ -0,0 +1,29 @@
+package quote
+
+import (
+	"regexp"
+	"strings"
+)
+
+var unsafe = regexp.MustCompile(`[^\w@%+=:,./-]`)
+
+// Quote quotes the argument for POSIX shells. Arguments that need no quoting
+// are returned as is.
+func Quote(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	if !unsafe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+}
+
+// Join quotes the arguments and joins them into a single command line.
+func Join(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
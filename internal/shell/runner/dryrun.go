@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/eskelinenantti/tmuxide/internal/shell/quote"
+)
+
+// DryRunner prints the commands instead of running them. Commands that only
+// read, like tmux has-session, still run so that the printed commands are the
+// ones that would run, and are printed as comments.
+type DryRunner struct {
+	Runner Runner
+	Output io.Writer
+}
+
+func (d DryRunner) Run(cmd *exec.Cmd) error {
+	if IsQuery(cmd.Args) {
+		fmt.Fprintln(d.Output, "#", quote.Join(Redact(cmd.Args)))
+		return d.Runner.Run(cmd)
+	}
+	fmt.Fprintln(d.Output, quote.Join(Redact(cmd.Args)))
+	return nil
+}
+
+func (d DryRunner) Start(cmd *exec.Cmd) (WriteCloser, error) {
+	if IsQuery(cmd.Args) {
+		fmt.Fprintln(d.Output, "#", quote.Join(Redact(cmd.Args)))
+		return d.Runner.Start(cmd)
+	}
+	fmt.Fprintln(d.Output, quote.Join(Redact(cmd.Args)))
+	return discard{}, nil
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (discard) Close() error {
+	return nil
+}
+
+// queries are the subcommands of the programs that only read. fd, fzf and
+// ssh are left out, as every command tmuxide runs with them only reads.
+var queries = map[string][]string{
+	"tmux":   {"has-session", "list-sessions", "list-windows", "list-panes", "list-clients", "capture-pane", "show-options", "show-environment"},
+	"git":    {"rev-parse", "for-each-ref", "status", "rev-list"},
+	"docker": {"ps", "inspect"},
+	"podman": {"ps", "inspect"},
+}
+
+// flagsWithValue are the global flags of the programs that are followed by a
+// value, such as tmux -L name.
+var flagsWithValue = map[string][]string{
+	"tmux": {"-L", "-S", "-f"},
+	"git":  {"-C", "-c"},
+}
+
+// IsQuery reports whether the command only reads, and can be run without
+// changing anything.
+func IsQuery(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	program := args[0]
+	switch program {
+	case "fd", "fzf", "fzf-tmux", "ssh":
+		return true
+	}
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-V" || arg == "--version":
+			return true
+		case slices.Contains(flagsWithValue[program], arg):
+			i++
+		case strings.HasPrefix(arg, "-"):
+		case arg == "display-message":
+			// Without -p, the message is shown in the client
+			return program == "tmux" && slices.Contains(args[i:], "-p")
+		case arg == "worktree":
+			// git worktree add changes the repository, list only reads
+			return program == "git" && slices.Contains(args[i:], "list")
+		default:
+			return slices.Contains(queries[program], arg)
+		}
+	}
+	return false
+}
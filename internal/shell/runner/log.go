@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	"github.com/eskelinenantti/tmuxide/internal/shell/quote"
+)
+
+// LogRunner prints each command it runs, with how long the command took.
+type LogRunner struct {
+	Runner Runner
+	Output io.Writer
+}
+
+func (l LogRunner) Run(cmd *exec.Cmd) error {
+	start := time.Now()
+	err := l.Runner.Run(cmd)
+	l.log(cmd, time.Since(start), err)
+	return err
+}
+
+// Start logs the command once it exits, as the duration is not known before.
+func (l LogRunner) Start(cmd *exec.Cmd) (WriteCloser, error) {
+	start := time.Now()
+	writeCloser, err := l.Runner.Start(cmd)
+	if err != nil {
+		l.log(cmd, time.Since(start), err)
+		return nil, err
+	}
+	return loggedWriteCloser{writeCloser, func(err error) {
+		l.log(cmd, time.Since(start), err)
+	}}, nil
+}
+
+func (l LogRunner) log(cmd *exec.Cmd, duration time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = "failed"
+	}
+	fmt.Fprintf(l.Output, "%s (%s, %v)\n", quote.Join(Redact(cmd.Args)), status, duration.Round(time.Microsecond))
+}
+
+type loggedWriteCloser struct {
+	WriteCloser
+	log func(err error)
+}
+
+func (l loggedWriteCloser) Close() error {
+	err := l.WriteCloser.Close()
+	l.log(err)
+	return err
+}
+
+// SlogRunner records each command it runs in the log, with how long the
+// command took and why it failed.
+type SlogRunner struct {
+	Runner Runner
+	Logger *slog.Logger
+}
+
+func (s SlogRunner) Run(cmd *exec.Cmd) error {
+	start := time.Now()
+	err := s.Runner.Run(cmd)
+	s.log(cmd, time.Since(start), err)
+	return err
+}
+
+func (s SlogRunner) Start(cmd *exec.Cmd) (WriteCloser, error) {
+	start := time.Now()
+	writeCloser, err := s.Runner.Start(cmd)
+	if err != nil {
+		s.log(cmd, time.Since(start), err)
+		return nil, err
+	}
+	return loggedWriteCloser{writeCloser, func(err error) {
+		s.log(cmd, time.Since(start), err)
+	}}, nil
+}
+
+func (s SlogRunner) log(cmd *exec.Cmd, duration time.Duration, err error) {
+	command := quote.Join(Redact(cmd.Args))
+	if err != nil {
+		s.Logger.Warn("command failed", "command", command, "duration", duration, "err", err, "stderr", Stderr(err))
+		return
+	}
+	s.Logger.Info("command", "command", command, "duration", duration)
+}
@@ -1,10 +1,13 @@
 package runner
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os/exec"
+	"slices"
+	"strings"
 )
 
 var ErrCommandFailed = errors.New("command failed")
@@ -19,16 +22,43 @@ type Runner interface {
 	Start(cmd *exec.Cmd) (WriteCloser, error)
 }
 
+// Error is the error of a command that failed. Stderr holds what the command
+// printed to stderr, unless its stderr was connected elsewhere.
+type Error struct {
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("'%v' %v: %v", Redact(e.Args), ErrCommandFailed, e.Err)
+}
+
+func (e Error) Unwrap() []error {
+	return []error{ErrCommandFailed, e.Err}
+}
+
+// Stderr returns what the command that caused the error printed to stderr.
+func Stderr(err error) string {
+	var cmdErr Error
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Stderr
+	}
+	return ""
+}
+
 type CmdRunner struct{}
 type CmdWriteCloser struct {
-	cmd   *exec.Cmd
-	stdin io.WriteCloser
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *bytes.Buffer
 }
 
 func (c CmdRunner) Run(cmd *exec.Cmd) error {
+	stderr := captureStderr(cmd)
 	err := cmd.Run()
 	if err != nil {
-		return fmt.Errorf("'%v' %w: %w", cmd.Args, ErrCommandFailed, err)
+		return commandError(cmd, stderr, err)
 	}
 	return nil
 }
@@ -38,12 +68,13 @@ func (c CmdRunner) Start(cmd *exec.Cmd) (WriteCloser, error) {
 	if err != nil {
 		return nil, err
 	}
+	stderr := captureStderr(cmd)
 	err = cmd.Start()
 	if err != nil {
-		return nil, fmt.Errorf("'%v' %w: %w", cmd.Args, ErrCommandFailed, err)
+		return nil, commandError(cmd, stderr, err)
 	}
 
-	return CmdWriteCloser{cmd: cmd, stdin: stdin}, nil
+	return CmdWriteCloser{cmd: cmd, stdin: stdin, stderr: stderr}, nil
 }
 
 func (c CmdWriteCloser) Write(p []byte) (n int, err error) {
@@ -55,5 +86,72 @@ func (c CmdWriteCloser) Close() error {
 	if err != nil {
 		return err
 	}
-	return c.cmd.Wait()
+	err = c.cmd.Wait()
+	if err != nil {
+		return commandError(c.cmd, c.stderr, err)
+	}
+	return nil
+}
+
+// captureStderr collects the stderr of the command, unless it is already
+// connected elsewhere, e.g. to the terminal.
+func captureStderr(cmd *exec.Cmd) *bytes.Buffer {
+	if cmd.Stderr != nil {
+		return nil
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	return &stderr
+}
+
+func commandError(cmd *exec.Cmd, stderr *bytes.Buffer, err error) error {
+	cmdErr := Error{Args: cmd.Args, Err: err}
+	if stderr != nil {
+		cmdErr.Stderr = strings.TrimSpace(stderr.String())
+	}
+	return cmdErr
+}
+
+// Redact returns the arguments with the values of the environment variables
+// masked, so that secrets don't end up in error messages and logs. The
+// variables are the ones given with -e, to env, and to tmux set-environment.
+func Redact(args []string) []string {
+	redacted := slices.Clone(args)
+	env := false
+	for i := 1; i < len(redacted); i++ {
+		name, _, isVariable := strings.Cut(redacted[i], "=")
+		switch {
+		case redacted[i-1] == "-e" && isVariable:
+			redacted[i] = name + "=***"
+		case redacted[i] == "env":
+			env = true
+		case env && isVariable:
+			redacted[i] = name + "=***"
+		case env && !strings.HasPrefix(redacted[i], "-"):
+			// The command run by env
+			env = false
+		case redacted[i] == "set-environment":
+			redactValue(redacted[i+1:])
+			return redacted
+		}
+	}
+	return redacted
+}
+
+// redactValue masks the value of the arguments of tmux set-environment, which
+// follows the name of the variable.
+func redactValue(args []string) {
+	var positional []int
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-t":
+			i++
+		case strings.HasPrefix(args[i], "-"):
+		default:
+			positional = append(positional, i)
+		}
+	}
+	if len(positional) == 2 {
+		args[positional[1]] = "***"
+	}
 }
@@ -3,17 +3,22 @@ package shell
 import (
 	"errors"
 	"fmt"
+	"io"
 
+	"github.com/eskelinenantti/tmuxide/internal/shell/container"
 	"github.com/eskelinenantti/tmuxide/internal/shell/fd"
 	"github.com/eskelinenantti/tmuxide/internal/shell/fzf"
 	"github.com/eskelinenantti/tmuxide/internal/shell/git"
+	"github.com/eskelinenantti/tmuxide/internal/shell/hook"
 	"github.com/eskelinenantti/tmuxide/internal/shell/path"
 	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/ssh"
 	"github.com/eskelinenantti/tmuxide/internal/shell/tmux"
+	"github.com/eskelinenantti/tmuxide/internal/shell/version"
 )
 
 var ErrCommandNotInstalled = errors.New("not installed")
-var dependencies = []string{"tmux", "fd", "fzf", "git"}
+var Dependencies = []string{"tmux", "fd", "fzf", "git"}
 
 type NotInstalledError struct {
 	Cmd string
@@ -28,24 +33,36 @@ func (e NotInstalledError) Unwrap() error {
 }
 
 type Shell struct {
-	Tmux tmux.Cmd
-	Fd   fd.Cmd
-	Fzf  fzf.Cmd
-	Git  git.Cmd
+	Tmux      tmux.Cmd
+	Fd        fd.Cmd
+	Fzf       fzf.Cmd
+	Git       git.Cmd
+	Ssh       ssh.Cmd
+	Container container.Cmd
+	Hook      hook.Cmd
+	// DryRun tells that the commands that change something are printed
+	// instead of run, so the files and folders they would create are missing.
+	DryRun bool
+	// Stderr receives the warnings about the projects that are opened.
+	Stderr io.Writer
 }
 
 func Init(path path.ShellPath, runner runner.Runner) (Shell, error) {
-	for _, dependency := range dependencies {
+	for _, dependency := range Dependencies {
 		if err := assertInstalled(dependency, path); err != nil {
 			return Shell{}, err
 		}
 	}
 
+	versions := version.Cmd{Runner: runner, Cache: &version.Cache{}}
 	return Shell{
-		Tmux: tmux.Cmd{Runner: runner},
-		Fd:   fd.Cmd{Runner: runner},
-		Fzf:  fzf.Cmd{Runner: runner},
-		Git:  git.Cmd{Runner: runner},
+		Tmux:      tmux.Cmd{Runner: runner, Versions: versions},
+		Fd:        fd.Cmd{Runner: runner},
+		Fzf:       fzf.Cmd{Runner: runner, Versions: versions, Path: path},
+		Git:       git.Cmd{Runner: runner},
+		Ssh:       ssh.Cmd{Runner: runner, Program: "ssh"},
+		Container: container.Cmd{Runner: runner},
+		Hook:      hook.Cmd{Runner: runner},
 	}, nil
 }
 
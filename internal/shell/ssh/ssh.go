@@ -0,0 +1,104 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/eskelinenantti/tmuxide/internal/project"
+	"github.com/eskelinenantti/tmuxide/internal/shell/quote"
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+)
+
+// resolveScript prints the absolute path of the target, whether it is a
+// directory, and the root of the repository the target is in. The directory
+// is resolved with cd and pwd, which every shell has, and with realpath only
+// if the directory can't be entered.
+const resolveScript = `[ -e "$1" ] || exit 1
+abs() { (CDPATH= cd -- "$1" 2>/dev/null && pwd -P) || realpath -- "$1"; }
+if [ -d "$1" ]; then
+	d=$(abs "$1") || exit 1
+	echo "$d"; echo d
+else
+	d=$(abs "$(dirname -- "$1")") || exit 1
+	echo "${d%/}/$(basename -- "$1")"; echo f
+fi
+git -C "$d" rev-parse --show-toplevel 2>/dev/null || true`
+
+// listScript lists the files and folders in the home directory with fd, or
+// with find if fd is not installed, relative to the home directory.
+const listScript = `cd || exit 1
+fd=$(command -v fd || command -v fdfind)
+if [ -n "$fd" ]; then
+	"$fd" --follow --hidden --exclude '{.git,node_modules,Library}' .
+else
+	find -L . -mindepth 1 \( -name .git -o -name node_modules -o -name Library \) -prune -o -print | sed 's|^\./||'
+fi`
+
+type Cmd struct {
+	runner.Runner
+	// Program is the ssh command, which can be replaced with any command
+	// taking the same arguments.
+	Program string
+}
+
+// Remote runs commands in a directory on a remote host.
+type Remote struct {
+	Program string
+	Host    string
+	Dir     string
+}
+
+// Resolve returns the absolute path of the target on the host, whether it is a
+// directory, and the root of the git repository the target is in. The root is
+// empty if the target is not inside a repository.
+func (s Cmd) Resolve(host string, target string) (string, bool, string, error) {
+	script := quote.Join([]string{"sh", "-c", resolveScript, "sh", target})
+	cmd := exec.Command(s.Program, host, script)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := s.Run(cmd); err != nil {
+		return "", false, "", err
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) < 2 {
+		return "", false, "", project.ErrInvalidPath
+	}
+
+	var repository string
+	if len(lines) > 2 {
+		repository = lines[2]
+	}
+	return lines[0], lines[1] == "d", repository, nil
+}
+
+// List writes the files and folders in the home directory of the remote user
+// to the output, one per line. The listing is cancelled with the context.
+func (s Cmd) List(ctx context.Context, host string, output io.Writer) error {
+	script := quote.Join([]string{"sh", "-c", listScript})
+	cmd := exec.CommandContext(ctx, s.Program, "-o", "BatchMode=yes", host, script)
+	cmd.Stdout = output
+	return s.Run(cmd)
+}
+
+func (s Cmd) Exec(host string, dir string) project.Exec {
+	return Remote{Program: s.Program, Host: host, Dir: dir}
+}
+
+// Command returns the ssh command that runs the command on the remote host, or
+// the login shell of the remote user if no command is given.
+func (r Remote) Command(command []string) []string {
+	script := "exec $SHELL -l"
+	if len(command) > 0 {
+		script = "exec " + quote.Join(command)
+	}
+	return []string{r.Program, "-t", r.Host, "cd " + quote.Quote(r.Dir) + " && " + script}
+}
+
+// Path returns the path as is, as paths on the host are resolved on the host.
+func (r Remote) Path(path string) string {
+	return path
+}
@@ -1,34 +1,305 @@
 package tmux
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
+	"strings"
 
 	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+	"github.com/eskelinenantti/tmuxide/internal/shell/version"
 )
 
+var ErrSessionNotFound = errors.New("session not found")
+var ErrServerNotRunning = errors.New("tmux server not running")
+var ErrNoClient = errors.New("no tmux client")
+
 type Cmd struct {
 	runner.Runner
+	Socket Socket
+	// Client is the tty of the client to switch. When empty, tmux switches
+	// the current client.
+	Client string
+	// Versions tells which features the installed tmux supports.
+	Versions version.Cmd
+}
+
+// Run runs the tmux command, telling apart the failures with a known cause by
+// what tmux printed to stderr.
+func (t Cmd) Run(cmd *exec.Cmd) error {
+	err := t.Runner.Run(cmd)
+	if err == nil {
+		return nil
+	}
+
+	stderr := runner.Stderr(err)
+	switch {
+	case strings.Contains(stderr, "no server running"), strings.Contains(stderr, "error connecting to"):
+		return fmt.Errorf("%w: %w", ErrServerNotRunning, err)
+	case strings.Contains(stderr, "can't find session"), strings.Contains(stderr, "no sessions"):
+		return fmt.Errorf("%w: %w", ErrSessionNotFound, err)
+	case strings.Contains(stderr, "no current client"), strings.Contains(stderr, "can't find client"):
+		return fmt.Errorf("%w: %w", ErrNoClient, err)
+	}
+	return err
+}
+
+// Socket selects the tmux server to use. A value containing a slash is a path
+// to the socket, otherwise it is the socket name. When empty, tmux uses the
+// server of the current client, or the default server outside tmux.
+type Socket string
+
+func (s Socket) args() []string {
+	switch {
+	case s == "":
+		return nil
+	case strings.Contains(string(s), "/"):
+		return []string{"-S", string(s)}
+	default:
+		return []string{"-L", string(s)}
+	}
+}
+
+type Session struct {
+	Name string
+	Path string
+	// Remote is the remote target the session was created for, if any.
+	Remote string
+}
+
+// remoteOption is the session option that keeps the remote target of the
+// sessions of remote projects, whose path is the directory ide was run in.
+const remoteOption = "@tmuxide_remote"
+
+// sessionFormat prints the fields of a session separated by tabs.
+const sessionFormat = "#{session_name}\t#{session_path}\t#{" + remoteOption + "}"
+
+// parseSession parses a session printed with sessionFormat.
+func parseSession(line string) Session {
+	name, rest, _ := strings.Cut(strings.TrimSuffix(line, "\n"), "\t")
+	path, remote, _ := strings.Cut(rest, "\t")
+	return Session{Name: name, Path: path, Remote: remote}
+}
+
+// Pane is a pane of a session, with the window it is in.
+type Pane struct {
+	WindowIndex  string
+	WindowName   string
+	WindowActive bool
+	WindowLayout string
+	Path         string
+}
+
+func (t Cmd) ListSessions() ([]Session, error) {
+	tmuxCmd := t.command("list-sessions", Args{Format: sessionFormat})
+	var out bytes.Buffer
+	tmuxCmd.Stdout = &out
+	if err := t.Run(tmuxCmd); err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	for line := range strings.Lines(out.String()) {
+		sessions = append(sessions, parseSession(line))
+	}
+	return sessions, nil
 }
 
 func (t Cmd) HasSession(targetSession string, targetWindow string) bool {
-	tmuxCmd := tmuxCommand("has-session", Args{TargetSession: targetSession, TargetWindow: targetWindow})
+	tmuxCmd := t.command("has-session", Args{TargetSession: targetSession, TargetWindow: targetWindow})
 	return t.Run(tmuxCmd) == nil
 }
 
-func (t Cmd) New(session string, dir string, cmd []string) error {
-	tmuxCmd := tmuxCommand("new-session", Args{SessionName: session, Detach: true, WorkingDir: dir, Command: cmd})
-	return t.Run(tmuxCmd)
+// New creates a detached session with the KEY=value variables of env in its
+// environment, which every window of the session inherits. tmux versions
+// without new-session -e get the variables set after creating the session,
+// and the first window, which is already running by then, gets them from env.
+func (t Cmd) New(session string, dir string, window string, env []string, cmd []string) error {
+	if len(env) == 0 || t.Versions.Supports(version.TmuxSessionEnvironment) {
+		tmuxCmd := t.command("new-session", Args{SessionName: session, Detach: true, WorkingDir: dir, Environment: env, WindowName: window, Command: cmd})
+		return t.Run(tmuxCmd)
+	}
+
+	if len(cmd) == 0 {
+		cmd = []string{"sh", "-c", `exec "${SHELL:-sh}" -l`}
+	}
+	cmd = slices.Concat([]string{"env"}, env, cmd)
+	tmuxCmd := t.command("new-session", Args{SessionName: session, Detach: true, WorkingDir: dir, WindowName: window, Command: cmd})
+	if err := t.Run(tmuxCmd); err != nil {
+		return err
+	}
+	for _, variable := range env {
+		name, value, _ := strings.Cut(variable, "=")
+		tmuxCmd := t.command("set-environment", Args{TargetSession: session, Command: []string{name, value}})
+		if err := t.Run(tmuxCmd); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (t Cmd) NewWindow(session string, window string, workingDir string, name string, cmd []string) error {
-	tmuxCmd := tmuxCommand("new-window", Args{Kill: true, WindowName: name, WorkingDir: workingDir, TargetSession: session, TargetWindow: window, Command: cmd})
+	tmuxCmd := t.command("new-window", Args{Kill: true, WindowName: name, WorkingDir: workingDir, TargetSession: session, TargetWindow: window, Command: cmd})
+	return t.Run(tmuxCmd)
+}
+
+// AddWindow creates a window in the session without selecting it, and returns
+// the index of the window. Windows are targeted by index, as tmux rejects
+// names that more than one window has.
+func (t Cmd) AddWindow(session string, workingDir string, name string, cmd []string) (string, error) {
+	tmuxCmd := t.command("new-window", Args{TargetSession: session, WorkingDir: workingDir, Detach: true, PrintTarget: true, Format: "#{window_index}", WindowName: name, Command: cmd})
+	var out bytes.Buffer
+	tmuxCmd.Stdout = &out
+	if err := t.Run(tmuxCmd); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// WindowName replaces the dots and colons of the window name, which tmux
+// would interpret as part of a target.
+func WindowName(name string) string {
+	return strings.NewReplacer(".", "_", ":", "_").Replace(name)
+}
+
+// SendKeys types the keys into the window and presses enter.
+func (t Cmd) SendKeys(session string, window string, keys string) error {
+	tmuxCmd := t.command("send-keys", Args{TargetSession: session, TargetWindow: window, Command: []string{keys, "Enter"}})
+	return t.Run(tmuxCmd)
+}
+
+func (t Cmd) SetOption(session string, option string, value string) error {
+	tmuxCmd := t.command("set-option", Args{TargetSession: session, Command: []string{option, value}})
+	return t.Run(tmuxCmd)
+}
+
+// ListPanes returns the panes of every window of the session.
+func (t Cmd) ListPanes(session string) ([]Pane, error) {
+	tmuxCmd := t.command("list-panes", Args{AllWindows: true, TargetSession: session, Format: "#{window_index}\t#{window_active}\t#{window_layout}\t#{pane_current_path}\t#{window_name}"})
+	var out bytes.Buffer
+	tmuxCmd.Stdout = &out
+	if err := t.Run(tmuxCmd); err != nil {
+		return nil, err
+	}
+
+	var panes []Pane
+	for line := range strings.Lines(out.String()) {
+		fields := strings.SplitN(strings.TrimSuffix(line, "\n"), "\t", 5)
+		if len(fields) < 5 {
+			continue
+		}
+		panes = append(panes, Pane{
+			WindowIndex:  fields[0],
+			WindowActive: fields[1] == "1",
+			WindowLayout: fields[2],
+			Path:         fields[3],
+			WindowName:   fields[4],
+		})
+	}
+	return panes, nil
+}
+
+// AddPane splits the window of the session without selecting the new pane.
+func (t Cmd) AddPane(session string, window string, workingDir string, cmd []string) error {
+	tmuxCmd := t.command("split-window", Args{TargetSession: session, TargetWindow: window, WorkingDir: workingDir, Detach: true, Command: cmd})
+	return t.Run(tmuxCmd)
+}
+
+func (t Cmd) SelectLayout(session string, window string, layout string) error {
+	tmuxCmd := t.command("select-layout", Args{TargetSession: session, TargetWindow: window, Command: []string{layout}})
+	return t.Run(tmuxCmd)
+}
+
+func (t Cmd) SelectWindow(session string, window string) error {
+	tmuxCmd := t.command("select-window", Args{TargetSession: session, TargetWindow: window})
+	return t.Run(tmuxCmd)
+}
+
+// OpenWindow creates a window running cmd in the session of the current client.
+func (t Cmd) OpenWindow(workingDir string, name string, cmd []string) error {
+	tmuxCmd := t.command("new-window", Args{WorkingDir: workingDir, WindowName: name, Command: cmd})
+	return t.Run(tmuxCmd)
+}
+
+// Split splits the current pane and runs cmd in the new pane. The panes are
+// placed side by side if horizontal is set, otherwise on top of each other.
+func (t Cmd) Split(workingDir string, horizontal bool, cmd []string) error {
+	tmuxCmd := t.command("split-window", Args{WorkingDir: workingDir, Horizontal: horizontal, Vertical: !horizontal, Command: cmd})
+	return t.Run(tmuxCmd)
+}
+
+// Popup runs cmd in a popup on top of the current client, closing it when cmd exits.
+func (t Cmd) Popup(workingDir string, cmd []string) error {
+	tmuxCmd := t.command("display-popup", Args{StartDirectory: workingDir, CloseOnExit: true, Command: cmd})
+	return t.Run(tmuxCmd)
+}
+
+// PanePath returns the working directory of the current pane.
+func (t Cmd) PanePath() (string, error) {
+	tmuxCmd := t.command("display-message", Args{Print: true, Command: []string{"#{pane_current_path}"}})
+	var out bytes.Buffer
+	tmuxCmd.Stdout = &out
+	err := t.Run(tmuxCmd)
+	return strings.TrimSpace(out.String()), err
+}
+
+// CapturePane returns the visible contents of the current pane.
+func (t Cmd) CapturePane() (string, error) {
+	tmuxCmd := t.command("capture-pane", Args{Print: true, JoinLines: true})
+	var out bytes.Buffer
+	tmuxCmd.Stdout = &out
+	err := t.Run(tmuxCmd)
+	return out.String(), err
+}
+
+// SocketPath returns the path of the socket of the server.
+func (t Cmd) SocketPath() (string, error) {
+	tmuxCmd := t.command("display-message", Args{Print: true, Command: []string{"#{socket_path}"}})
+	var out bytes.Buffer
+	tmuxCmd.Stdout = &out
+	err := t.Run(tmuxCmd)
+	return strings.TrimSpace(out.String()), err
+}
+
+// CurrentSession returns the session of the client tmuxide runs in.
+func (t Cmd) CurrentSession() (Session, error) {
+	tmuxCmd := t.command("display-message", Args{Print: true, Command: []string{sessionFormat}})
+	var out bytes.Buffer
+	tmuxCmd.Stdout = &out
+	if err := t.Run(tmuxCmd); err != nil {
+		return Session{}, err
+	}
+	return parseSession(out.String()), nil
+}
+
+// SetRemote records the remote target the session was created for.
+func (t Cmd) SetRemote(session string, target string) error {
+	return t.SetOption(session, remoteOption, target)
+}
+
+func (t Cmd) KillSession(session string) error {
+	tmuxCmd := t.command("kill-session", Args{TargetSession: session})
+	return t.Run(tmuxCmd)
+}
+
+// DisplayMessage shows the message in the status line of the client.
+func (t Cmd) DisplayMessage(message string) error {
+	// Keep tmux from expanding formats in the message
+	message = strings.ReplaceAll(message, "#", "##")
+	tmuxCmd := t.command("display-message", Args{TargetClient: t.Client, Command: []string{message}})
 	return t.Run(tmuxCmd)
 }
 
 func (t Cmd) Attach(session string) error {
-	tmuxCmd := tmuxCommand("attach", Args{TargetSession: session})
+	tmuxCmd := t.command("attach", Args{TargetSession: session})
+	// Attaching from inside a client of another server nests the clients,
+	// which tmux refuses to do while $TMUX is set.
+	tmuxCmd.Env = slices.DeleteFunc(os.Environ(), func(env string) bool {
+		return strings.HasPrefix(env, "TMUX=")
+	})
 	tmuxCmd.Stdin = os.Stdin
 	tmuxCmd.Stdout = os.Stdout
 	tmuxCmd.Stderr = os.Stderr
@@ -36,11 +307,12 @@ func (t Cmd) Attach(session string) error {
 }
 
 func (t Cmd) Switch(session string) error {
-	tmuxCmd := tmuxCommand("switch-client", Args{TargetSession: session})
+	tmuxCmd := t.command("switch-client", Args{TargetClient: t.Client, TargetSession: session})
 	return t.Run(tmuxCmd)
 }
 
 type Args struct {
+	TargetClient  string
 	TargetSession string
 	TargetWindow  string
 	Detach        bool
@@ -49,11 +321,33 @@ type Args struct {
 	WorkingDir    string
 	Command       []string
 	Kill          bool
+	Horizontal    bool
+	Vertical      bool
+	// StartDirectory is the working directory of a popup, which unlike other
+	// commands takes it with -d.
+	StartDirectory string
+	CloseOnExit    bool
+	Format         string
+	Print          bool
+	// PrintTarget prints the new window in Format.
+	PrintTarget bool
+	Environment []string
+	AllWindows  bool
+	// JoinLines joins the lines a pane has wrapped when capturing it.
+	JoinLines bool
 }
 
 func (a Args) Parse() []string {
 	args := []string{}
 
+	if a.TargetClient != "" {
+		args = append(args, "-c", a.TargetClient)
+	}
+
+	if a.AllWindows {
+		args = append(args, "-s")
+	}
+
 	if a.TargetSession != "" || a.TargetWindow != "" {
 		args = append(args, "-t", fmt.Sprintf("%s:%s", a.TargetSession, a.TargetWindow))
 	}
@@ -62,14 +356,50 @@ func (a Args) Parse() []string {
 		args = append(args, "-c", a.WorkingDir)
 	}
 
+	if a.StartDirectory != "" {
+		args = append(args, "-d", a.StartDirectory)
+	}
+
 	if a.Detach {
 		args = append(args, "-d")
 	}
 
+	for _, env := range a.Environment {
+		args = append(args, "-e", env)
+	}
+
 	if a.Kill {
 		args = append(args, "-k")
 	}
 
+	if a.Horizontal {
+		args = append(args, "-h")
+	}
+
+	if a.Vertical {
+		args = append(args, "-v")
+	}
+
+	if a.CloseOnExit {
+		args = append(args, "-E")
+	}
+
+	if a.Print {
+		args = append(args, "-p")
+	}
+
+	if a.PrintTarget {
+		args = append(args, "-P")
+	}
+
+	if a.JoinLines {
+		args = append(args, "-J")
+	}
+
+	if a.Format != "" {
+		args = append(args, "-F", a.Format)
+	}
+
 	if a.SessionName != "" {
 		args = append(args, "-s", a.SessionName)
 	}
@@ -85,8 +415,9 @@ func (a Args) Parse() []string {
 	return args
 }
 
-func tmuxCommand(subCommand string, args Args) *exec.Cmd {
-	cmd := exec.Command("tmux", subCommand)
+func (t Cmd) command(subCommand string, args Args) *exec.Cmd {
+	cmd := exec.Command("tmux", t.Socket.args()...)
+	cmd.Args = append(cmd.Args, subCommand)
 	cmd.Args = append(cmd.Args, args.Parse()...)
 	return cmd
 }
@@ -0,0 +1,137 @@
+package version
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
+)
+
+var ErrUnknownVersion = errors.New("unknown version")
+
+// number matches versions such as 3.4, 3.3a and 0.54.3 in the output of the
+// version flags of the dependencies.
+var number = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// Parse returns the first version in the output of a version flag, for
+// example "tmux 3.3a" or "0.54.0 (brew)".
+func Parse(output string) (Version, error) {
+	match := number.FindStringSubmatch(output)
+	if match == nil {
+		return Version{}, fmt.Errorf("%w: %q", ErrUnknownVersion, output)
+	}
+
+	var version Version
+	version.Major, _ = strconv.Atoi(match[1])
+	version.Minor, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		version.Patch, _ = strconv.Atoi(match[3])
+	}
+	return version, nil
+}
+
+// AtLeast reports whether the version is the given version or newer.
+func (v Version) AtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	return v.Patch >= other.Patch
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Feature is a feature of a dependency that older versions lack.
+type Feature struct {
+	Program string
+	Name    string
+	// Since is the version that introduced the feature.
+	Since Version
+}
+
+var (
+	TmuxPopup              = Feature{Program: "tmux", Name: "popups", Since: Version{Major: 3, Minor: 2}}
+	TmuxSessionEnvironment = Feature{Program: "tmux", Name: "new-session -e", Since: Version{Major: 3, Minor: 2}}
+	FzfTmux                = Feature{Program: "fzf", Name: "--tmux", Since: Version{Minor: 53}}
+)
+
+// Features are the features tmuxide uses when they are available.
+var Features = []Feature{TmuxPopup, TmuxSessionEnvironment, FzfTmux}
+
+// Cache keeps the versions probed during a run, so that each program is
+// asked for its version only once.
+type Cache struct {
+	probes map[string]probe
+}
+
+type probe struct {
+	version Version
+	err     error
+}
+
+type Cmd struct {
+	runner.Runner
+	// Cache is shared by the commands of a run. Without it, the versions are
+	// probed every time.
+	Cache *Cache
+}
+
+// Of returns the version of the program. tmux prints its version with -V,
+// and the other programs with --version.
+func (c Cmd) Of(program string) (Version, error) {
+	if c.Cache != nil {
+		if probe, ok := c.Cache.probes[program]; ok {
+			return probe.version, probe.err
+		}
+	}
+
+	version, err := c.probe(program)
+	if c.Cache != nil {
+		if c.Cache.probes == nil {
+			c.Cache.probes = map[string]probe{}
+		}
+		c.Cache.probes[program] = probe{version, err}
+	}
+	return version, err
+}
+
+// Supports reports whether the installed version of the program has the
+// feature. When the version can't be determined, the feature is assumed to
+// be supported, as it is by any recent version.
+func (c Cmd) Supports(feature Feature) bool {
+	if c.Runner == nil {
+		return true
+	}
+
+	version, err := c.Of(feature.Program)
+	return err != nil || version.AtLeast(feature.Since)
+}
+
+func (c Cmd) probe(program string) (Version, error) {
+	flag := "--version"
+	if program == "tmux" {
+		flag = "-V"
+	}
+
+	cmd := exec.Command(program, flag)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := c.Run(cmd); err != nil {
+		return Version{}, err
+	}
+	return Parse(out.String())
+}
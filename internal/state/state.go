@@ -0,0 +1,136 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+
+	"github.com/eskelinenantti/tmuxide/internal/xdg"
+)
+
+// Session is a session managed by tmuxide, recorded so that it can be
+// recreated after the tmux server has been restarted.
+type Session struct {
+	Name string `json:"name"`
+	// Target is the file or folder the session was opened for, which is
+	// resolved again when restoring the session.
+	Target string `json:"target"`
+	// Root is the working directory of the session, empty for sessions of
+	// projects that are not on the local machine.
+	Root    string   `json:"root"`
+	Windows []Window `json:"windows"`
+}
+
+type Window struct {
+	// Index is the index of the window when it was recorded. Restored windows
+	// get the next free index instead.
+	Index  string `json:"index,omitempty"`
+	Name   string `json:"name"`
+	Active bool   `json:"active,omitempty"`
+	Layout string `json:"layout,omitempty"`
+	// Panes are the working directories of the panes of the window.
+	Panes []string `json:"panes"`
+	// Command is the command tmuxide started in the first pane, if any.
+	Command []string `json:"command,omitempty"`
+	// Keys are typed into the shell of the first pane, if not empty.
+	Keys string `json:"keys,omitempty"`
+}
+
+// Path returns the path of the file the sessions are recorded to.
+func Path() string {
+	return filepath.Join(xdg.StateHome(), "sessions.json")
+}
+
+// Load returns the recorded sessions.
+func Load() ([]Session, error) {
+	data, err := os.ReadFile(Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("%s: %w", Path(), err)
+	}
+	return sessions, nil
+}
+
+// Find returns the recorded session with the name.
+func Find(sessions []Session, name string) (Session, bool) {
+	i := slices.IndexFunc(sessions, func(session Session) bool {
+		return session.Name == name
+	})
+	if i < 0 {
+		return Session{}, false
+	}
+	return sessions[i], true
+}
+
+// Put records the session, replacing the earlier record of the session.
+func Put(session Session) error {
+	return update(func(sessions []Session) []Session {
+		sessions = slices.DeleteFunc(sessions, func(s Session) bool {
+			return s.Name == session.Name
+		})
+		return append(sessions, session)
+	})
+}
+
+// Remove forgets the session.
+func Remove(name string) error {
+	return update(func(sessions []Session) []Session {
+		return slices.DeleteFunc(sessions, func(s Session) bool {
+			return s.Name == name
+		})
+	})
+}
+
+// update replaces the recorded sessions with the result of change. The state
+// file stays locked from loading to saving, so that the changes of ide runs
+// at the same time are not lost.
+func update(change func([]Session) []Session) error {
+	unlock, err := lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sessions, err := Load()
+	if err != nil {
+		return err
+	}
+	return save(change(sessions))
+}
+
+// lock takes an exclusive lock on a lock file next to the state file, which
+// the file itself can't hold as saving replaces it.
+func lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(Path()), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(Path()+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() { file.Close() }, nil
+}
+
+func save(sessions []Session) error {
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return xdg.WriteFile(Path(), data)
+}
@@ -2,6 +2,7 @@ package spy
 
 import (
 	"os/exec"
+	"sync"
 
 	"github.com/eskelinenantti/tmuxide/internal/shell/runner"
 )
@@ -15,6 +16,9 @@ type Response struct {
 type SpyRunner struct {
 	Calls     [][]string
 	Responses []Response
+	// mutex guards the calls and responses of commands run at the same time,
+	// such as listing remote hosts.
+	mutex sync.Mutex
 }
 
 type FakeWriteCloser struct{}
@@ -24,18 +28,11 @@ func (f FakeWriteCloser) Close() error {
 }
 
 func (f FakeWriteCloser) Write(p []byte) (n int, err error) {
-	return 0, nil
+	return len(p), nil
 }
 
 func (t *SpyRunner) Run(cmd *exec.Cmd) error {
-	t.Calls = append(t.Calls, cmd.Args)
-
-	if len(t.Responses) == 0 {
-		return nil
-	}
-
-	response := t.Responses[0]
-	t.Responses = t.Responses[1:]
+	response := t.respond(cmd)
 	if response.OnRun == nil {
 		return nil
 	}
@@ -45,3 +42,16 @@ func (t *SpyRunner) Run(cmd *exec.Cmd) error {
 func (t *SpyRunner) Start(cmd *exec.Cmd) (runner.WriteCloser, error) {
 	return FakeWriteCloser{}, t.Run(cmd)
 }
+
+func (t *SpyRunner) respond(cmd *exec.Cmd) Response {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.Calls = append(t.Calls, cmd.Args)
+	if len(t.Responses) == 0 {
+		return Response{}
+	}
+	response := t.Responses[0]
+	t.Responses = t.Responses[1:]
+	return response
+}
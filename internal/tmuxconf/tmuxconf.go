@@ -0,0 +1,110 @@
+// Package tmuxconf adds the key bindings of tmuxide to the tmux configuration,
+// between markers so that they can be updated and removed again.
+package tmuxconf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	begin = "# >>> tmuxide >>>"
+	end   = "# <<< tmuxide <<<"
+)
+
+// ErrUnterminated tells that the configuration has the begin marker of the
+// block without the end marker, so the end of the block is not known.
+var ErrUnterminated = errors.New("the key bindings of tmuxide have no end marker")
+
+// Block is the recommended configuration: prefix o picks a project, prefix O
+// opens a menu of the other actions, and C-o opens the file under the cursor
+// in copy mode, where the word under the cursor is known.
+const Block = begin + `
+# Added by ide tmux-install, remove with ide tmux-uninstall
+bind-key o run-shell "ide"
+bind-key O display-menu -T "#[align=centre]tmuxide" \
+  "Pick project" p "run-shell 'ide'" \
+  "Recent sessions" r "choose-tree -Zs -O time" \
+  "Kill this project session" k "run-shell 'ide kill'" \
+  "Open file from pane" o "run-shell 'ide pick-from-pane'"
+bind-key -T copy-mode C-o run-shell -c "#{pane_current_path}" "ide #{q:copy_cursor_word}"
+bind-key -T copy-mode-vi C-o run-shell -c "#{pane_current_path}" "ide #{q:copy_cursor_word}"
+` + end + "\n"
+
+// Path returns the tmux configuration file in use: ~/.tmux.conf, or the one
+// under $XDG_CONFIG_HOME if only that one exists.
+func Path() string {
+	home := os.Getenv("HOME")
+	path := filepath.Join(home, ".tmux.conf")
+	if fileExists(path) {
+		return path
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	if xdgPath := filepath.Join(configHome, "tmux", "tmux.conf"); fileExists(xdgPath) {
+		return xdgPath
+	}
+	return path
+}
+
+// Install returns the configuration with the block added to its end, or the
+// earlier block replaced with the current one.
+func Install(config string) (string, error) {
+	before, after, ok, err := cut(config)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return before + Block + after, nil
+	}
+
+	if config != "" && !strings.HasSuffix(config, "\n") {
+		config += "\n"
+	}
+	if config != "" {
+		config += "\n"
+	}
+	return config + Block, nil
+}
+
+// Uninstall returns the configuration without the block, and whether there
+// was a block to remove.
+func Uninstall(config string) (string, bool, error) {
+	before, after, ok, err := cut(config)
+	if err != nil || !ok {
+		return config, false, err
+	}
+	// Remove the empty line added before the block
+	if after == "" {
+		before = strings.TrimSuffix(before, "\n")
+	}
+	return before + after, true, nil
+}
+
+// cut returns the configuration before and after the block, and whether
+// there is a block.
+func cut(config string) (string, string, bool, error) {
+	start := strings.Index(config, begin)
+	if start < 0 {
+		return config, "", false, nil
+	}
+	stop := strings.Index(config[start:], end)
+	if stop < 0 {
+		return "", "", false, ErrUnterminated
+	}
+	stop += start + len(end)
+	if stop < len(config) && config[stop] == '\n' {
+		stop++
+	}
+	return config[:start], config[stop:], true, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
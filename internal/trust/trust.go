@@ -0,0 +1,122 @@
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/eskelinenantti/tmuxide/internal/xdg"
+)
+
+// Status tells whether the contents of a file have been trusted.
+type Status int
+
+const (
+	// Untrusted files have never been trusted.
+	Untrusted Status = iota
+	// Changed files have been trusted, but have changed since.
+	Changed
+	// Trusted files have been trusted as they are.
+	Trusted
+)
+
+// Path returns the path of the trust store, which maps the absolute paths of
+// trusted files to the hashes of their contents and the files they read.
+func Path() string {
+	return filepath.Join(xdg.DataHome(), "trusted.json")
+}
+
+// Check returns whether the file has been trusted with the data as its
+// contents. The files the file reads, such as env files, are trusted along
+// with it, so that changing any of them makes the file untrusted again.
+func Check(path string, data []byte, files []string) (Status, error) {
+	path, hash, err := hashFiles(path, data, files)
+	if err != nil {
+		return Untrusted, err
+	}
+
+	store, err := load()
+	if err != nil {
+		return Untrusted, err
+	}
+
+	trusted, ok := store[path]
+	switch {
+	case !ok:
+		return Untrusted, nil
+	case trusted != hash:
+		return Changed, nil
+	default:
+		return Trusted, nil
+	}
+}
+
+// Trust adds the file with the data as its contents, and the current contents
+// of the files it reads, to the trust store.
+func Trust(path string, data []byte, files []string) error {
+	path, hash, err := hashFiles(path, data, files)
+	if err != nil {
+		return err
+	}
+
+	store, err := load()
+	if err != nil {
+		return err
+	}
+	store[path] = hash
+
+	data, err = json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(Path()), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(Path(), data, 0600)
+}
+
+// hashFiles returns the absolute path of the file, and a hash of the data and
+// of the paths and contents of the files. Missing files are hashed as missing,
+// so that creating them changes the hash.
+func hashFiles(path string, data []byte, files []string) (string, string, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.New()
+	hash.Write(data)
+	for _, file := range files {
+		if file, err = filepath.Abs(file); err != nil {
+			return "", "", err
+		}
+		contents, err := os.ReadFile(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", "", err
+		}
+		fmt.Fprintf(hash, "\x00%s\x00%t\x00%d\x00", file, err == nil, len(contents))
+		hash.Write(contents)
+	}
+	return absolutePath, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func load() (map[string]string, error) {
+	store := map[string]string{}
+	data, err := os.ReadFile(Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("%s: %w", Path(), err)
+	}
+	return store, nil
+}
@@ -0,0 +1,62 @@
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const app = "tmuxide"
+
+// ConfigHome returns the directory of the tmuxide configuration files.
+func ConfigHome() string {
+	return dir("XDG_CONFIG_HOME", ".config")
+}
+
+// CacheHome returns the directory of the tmuxide cache files.
+func CacheHome() string {
+	return dir("XDG_CACHE_HOME", ".cache")
+}
+
+// DataHome returns the directory of the data files tmuxide keeps, such as the
+// trusted project configurations.
+func DataHome() string {
+	return dir("XDG_DATA_HOME", ".local/share")
+}
+
+// StateHome returns the directory of the state tmuxide keeps between runs,
+// such as the sessions it manages.
+func StateHome() string {
+	return dir("XDG_STATE_HOME", ".local/state")
+}
+
+func dir(env string, fallback string) string {
+	if base := os.Getenv(env); base != "" {
+		return filepath.Join(base, app)
+	}
+	return filepath.Join(os.Getenv("HOME"), fallback, app)
+}
+
+// WriteFile replaces the file with the data at once, by writing a temporary
+// file next to it and renaming it, so that ide runs at the same time never
+// read a partly written file. Missing directories are created, and the file
+// is readable by its owner only.
+func WriteFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}